@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/auth"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+// Scopes an API key can be granted. Each one lets the key stand in for an
+// access token on the endpoints that ask for it.
+const (
+	scopeChirpsRead  = "chirps:read"
+	scopeChirpsWrite = "chirps:write"
+	scopeUsersRead   = "users:read"
+)
+
+var apiKeyScopes = []string{scopeChirpsRead, scopeChirpsWrite, scopeUsersRead}
+
+const maxAPIKeyNameLength = 100
+const maxAPIKeyExpiresInDays = 3650
+
+// apiKeyPrefixLength is how much of a key is kept in clear, so that users can
+// recognize their keys in listings.
+const apiKeyPrefixLength = len(auth.APIKeyPrefix) + 6
+
+// apiKeyTouchInterval limits how often last_used_at is written for a key in
+// constant use.
+const apiKeyTouchInterval = time.Minute
+
+var errInvalidAPIKey = errors.New("invalid API key")
+var errMissingScope = errors.New("missing scope")
+var errCheckingAPIKey = errors.New("error checking API key")
+
+type APIKey struct {
+	ID			uuid.UUID	`json:"id"`
+	Name		string		`json:"name"`
+	Prefix		string		`json:"prefix"`
+	Scopes		[]string	`json:"scopes"`
+	CreatedAt	time.Time	`json:"created_at"`
+	ExpiresAt	*time.Time	`json:"expires_at"`
+	LastUsedAt	*time.Time	`json:"last_used_at"`
+	// Key is only set in the response to its creation.
+	Key			string		`json:"key,omitempty"`
+}
+
+// handlerCreateAPIKey creates a long-lived key with the given scopes. The key
+// is returned once; only its hash is stored.
+func (cfg *apiConfig) handlerCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name			string		`json:"name"`
+		Scopes			[]string	`json:"scopes"`
+		ExpiresInDays	*int		`json:"expires_in_days"`
+	}
+
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		log.Printf("error decoding request payload while creating api key: %v", err)
+		return
+	}
+
+	name, err := validateAPIKeyName(params.Name)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	scopes, err := validateAPIKeyScopes(params.Scopes)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	expiresAt := sql.NullTime{}
+	if params.ExpiresInDays != nil {
+		days := *params.ExpiresInDays
+		if days < 1 || days > maxAPIKeyExpiresInDays {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("expires_in_days must be between 1 and %d", maxAPIKeyExpiresInDays))
+			return
+		}
+		expiresAt = sql.NullTime{
+			Time:  time.Now().UTC().AddDate(0, 0, days),
+			Valid: true,
+		}
+	}
+
+	key, err := auth.MakeAPIKey()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error generating key")
+		log.Printf("error generating api key for user %s: %v", userID, err)
+		return
+	}
+
+	apiKey, err := cfg.db.CreateAPIKey(r.Context(), database.CreateAPIKeyParams{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		KeyHash:   auth.HashToken(key),
+		KeyPrefix: key[:apiKeyPrefixLength],
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error saving key")
+		log.Printf("error saving api key for user %s: %v", userID, err)
+		return
+	}
+
+	response := databaseAPIKeyToAPIKey(apiKey)
+	response.Key = key
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+func (cfg *apiConfig) handlerGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	apiKeys, err := cfg.db.GetAPIKeysForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving keys")
+		log.Printf("error retrieving api keys of user %s: %v", userID, err)
+		return
+	}
+
+	response := make([]APIKey, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		response[i] = databaseAPIKeyToAPIKey(apiKey)
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerGetAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	apiKey, ok := cfg.getOwnedAPIKey(w, r, userID)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseAPIKeyToAPIKey(apiKey))
+}
+
+// handlerUpdateAPIKey renames the {id} key. Scopes and expiry cannot be
+// changed; a new key has to be created instead.
+func (cfg *apiConfig) handlerUpdateAPIKey(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name	string	`json:"name"`
+	}
+
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	apiKeyID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid key ID")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		log.Printf("error decoding request payload while updating api key: %v", err)
+		return
+	}
+
+	name, err := validateAPIKeyName(params.Name)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	apiKey, err := cfg.db.UpdateAPIKeyName(r.Context(), database.UpdateAPIKeyNameParams{
+		ID:     apiKeyID,
+		UserID: userID,
+		Name:   name,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "key not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error updating key")
+		log.Printf("error updating api key %s of user %s: %v", apiKeyID, userID, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseAPIKeyToAPIKey(apiKey))
+}
+
+// handlerRevokeAPIKey revokes the {id} key. It stops working immediately.
+func (cfg *apiConfig) handlerRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	apiKeyID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid key ID")
+		return
+	}
+
+	revoked, err := cfg.db.RevokeAPIKey(r.Context(), database.RevokeAPIKeyParams{
+		ID:     apiKeyID,
+		UserID: userID,
+		RevokedAt: sql.NullTime{
+			Time:  time.Now().UTC(),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error revoking key")
+		log.Printf("error revoking api key %s of user %s: %v", apiKeyID, userID, err)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "key not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateAPIKey returns the owner of key if it is active and was granted
+// scope. Errors wrapping errMissingScope mean the key lacks scope, and
+// errors wrapping errCheckingAPIKey that it could not be looked up; any other
+// error means the key is not valid.
+func (cfg *apiConfig) validateAPIKey(ctx context.Context, key, scope string) (uuid.UUID, error) {
+	apiKey, err := cfg.db.GetAPIKeyByHash(ctx, auth.HashToken(key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, errInvalidAPIKey
+		}
+		log.Printf("error retrieving api key: %v", err)
+		return uuid.Nil, fmt.Errorf("%w: %w", errCheckingAPIKey, err)
+	}
+
+	now := time.Now().UTC()
+	if apiKey.RevokedAt.Valid || (apiKey.ExpiresAt.Valid && !now.Before(apiKey.ExpiresAt.Time)) {
+		return uuid.Nil, errInvalidAPIKey
+	}
+	if !slices.Contains(apiKey.Scopes, scope) {
+		return uuid.Nil, fmt.Errorf("%w %s", errMissingScope, scope)
+	}
+
+	if !apiKey.LastUsedAt.Valid || now.Sub(apiKey.LastUsedAt.Time) >= apiKeyTouchInterval {
+		err := cfg.db.TouchAPIKey(ctx, database.TouchAPIKeyParams{
+			ID:         apiKey.ID,
+			LastUsedAt: sql.NullTime{Time: now, Valid: true},
+		})
+		if err != nil {
+			log.Printf("error updating last use of api key %s: %v", apiKey.ID, err)
+		}
+	}
+
+	return apiKey.UserID, nil
+}
+
+// getOwnedAPIKey loads the {id} key of userID. Keys of other users are
+// reported as not found.
+func (cfg *apiConfig) getOwnedAPIKey(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.ApiKey, bool) {
+	apiKeyID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid key ID")
+		return database.ApiKey{}, false
+	}
+
+	apiKey, err := cfg.db.GetAPIKeyForUser(r.Context(), database.GetAPIKeyForUserParams{
+		ID:     apiKeyID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "key not found")
+			return database.ApiKey{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving key")
+		log.Printf("error retrieving api key %s of user %s: %v", apiKeyID, userID, err)
+		return database.ApiKey{}, false
+	}
+
+	return apiKey, true
+}
+
+func databaseAPIKeyToAPIKey(apiKey database.ApiKey) APIKey {
+	response := APIKey{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.KeyPrefix,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt,
+	}
+	if apiKey.ExpiresAt.Valid {
+		response.ExpiresAt = &apiKey.ExpiresAt.Time
+	}
+	if apiKey.LastUsedAt.Valid {
+		response.LastUsedAt = &apiKey.LastUsedAt.Time
+	}
+	return response
+}
+
+func validateAPIKeyName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("key name cannot be empty")
+	}
+	if len(name) > maxAPIKeyNameLength {
+		return "", fmt.Errorf("key name is too long")
+	}
+	return name, nil
+}
+
+// validateAPIKeyScopes checks that scopes are known and returns them sorted
+// without duplicates.
+func validateAPIKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	return slices.Compact(scopes), nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/auth"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+func TestRespondWithAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"invalid key", errInvalidAPIKey, http.StatusUnauthorized},
+		{"invalid jwt", errors.New("token is expired"), http.StatusUnauthorized},
+		{"missing scope", fmt.Errorf("%w %s", errMissingScope, scopeChirpsWrite), http.StatusForbidden},
+		{"database error", fmt.Errorf("%w: %w", errCheckingAPIKey, errors.New("connection refused")), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			respondWithAuthError(w, tt.err)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+// createTestAPIKey stores a key of userID with every scope and returns it.
+func createTestAPIKey(t *testing.T, cfg *apiConfig, userID uuid.UUID) string {
+	t.Helper()
+
+	key, err := auth.MakeAPIKey()
+	if err != nil {
+		t.Fatalf("making api key: %v", err)
+	}
+	_, err = cfg.db.CreateAPIKey(context.Background(), database.CreateAPIKeyParams{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      "test",
+		KeyHash:   auth.HashToken(key),
+		KeyPrefix: key[:apiKeyPrefixLength],
+		Scopes:    apiKeyScopes,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("creating api key: %v", err)
+	}
+	return key
+}
+
+func TestPasswordChangeRevokesAPIKeys(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com", "old password")
+	key := createTestAPIKey(t, cfg, user.ID)
+
+	if _, err := cfg.validateAPIKey(context.Background(), key, scopeChirpsRead); err != nil {
+		t.Fatalf("validateAPIKey before the change: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPut, "/api/users", strings.NewReader(`{"password": "new password"}`))
+	r.Header.Set("Authorization", accessToken(t, cfg, user.ID))
+	w := httptest.NewRecorder()
+	cfg.handlerUpdateUser(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	if _, err := cfg.validateAPIKey(context.Background(), key, scopeChirpsRead); err != errInvalidAPIKey {
+		t.Errorf("validateAPIKey after the change = %v, want %v", err, errInvalidAPIKey)
+	}
+}
+
+func TestPasswordResetRevokesAPIKeys(t *testing.T) {
+	cfg, mail := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com", "old password")
+	key := createTestAPIKey(t, cfg, user.ID)
+
+	requestPasswordReset(t, cfg, "walt@example.com")
+	token := resetTokenFrom(t, waitForEmail(t, mail, 1).Body)
+	if w := confirmPasswordReset(t, cfg, token, "new password"); w.Code != http.StatusNoContent {
+		t.Fatalf("confirm status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body)
+	}
+
+	if _, err := cfg.validateAPIKey(context.Background(), key, scopeChirpsRead); err != errInvalidAPIKey {
+		t.Errorf("validateAPIKey after the reset = %v, want %v", err, errInvalidAPIKey)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+// handlerBlockUser blocks the {id} user. Blocking also ends any follow
+// between the two users, in either direction.
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	blockerID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	blockedID, ok := cfg.requireTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	if blockerID == blockedID {
+		respondWithError(w, http.StatusBadRequest, "you cannot block yourself")
+		return
+	}
+
+	err = cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		err := q.BlockUser(r.Context(), database.BlockUserParams{
+			BlockerID: blockerID,
+			BlockedID: blockedID,
+			CreatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		return q.RemoveFollowsBetween(r.Context(), database.RemoveFollowsBetweenParams{
+			UserID:      blockerID,
+			OtherUserID: blockedID,
+		})
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "already blocking this user")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error blocking user")
+		log.Printf("error inserting block %s -> %s: %v", blockerID, blockedID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	blockerID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	blockedID, ok := cfg.requireTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.UnblockUser(r.Context(), database.UnblockUserParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error unblocking user")
+		log.Printf("error deleting block %s -> %s: %v", blockerID, blockedID, err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "not blocking this user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	muterID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	mutedID, ok := cfg.requireTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	if muterID == mutedID {
+		respondWithError(w, http.StatusBadRequest, "you cannot mute yourself")
+		return
+	}
+
+	err = cfg.db.MuteUser(r.Context(), database.MuteUserParams{
+		MuterID:   muterID,
+		MutedID:   mutedID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "already muting this user")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error muting user")
+		log.Printf("error inserting mute %s -> %s: %v", muterID, mutedID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	muterID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	mutedID, ok := cfg.requireTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.UnmuteUser(r.Context(), database.UnmuteUserParams{
+		MuterID: muterID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error unmuting user")
+		log.Printf("error deleting mute %s -> %s: %v", muterID, mutedID, err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "not muting this user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// isBlockedBetween reports whether either user blocks the other. Anonymous
+// viewers are never blocked.
+func (cfg *apiConfig) isBlockedBetween(ctx context.Context, viewerID uuid.NullUUID, userID uuid.UUID) (bool, error) {
+	if !viewerID.Valid || viewerID.UUID == userID {
+		return false, nil
+	}
+	return cfg.db.IsBlockedBetween(ctx, database.IsBlockedBetweenParams{
+		UserID:      viewerID.UUID,
+		OtherUserID: userID,
+	})
+}
@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/leonardomlouzas/GOose/internal/auth"
 	"github.com/leonardomlouzas/GOose/internal/database"
 )
 
@@ -20,21 +19,31 @@ type Chirp struct {
 	UpdatedAt	time.Time	`json:"updated_at"`
 	Body		string		`json:"body"`
 	UserID		uuid.UUID	`json:"user_id"`
+	ReplyToID	uuid.NullUUID	`json:"reply_to_id"`
+	LikeCount	int64		`json:"like_count"`
+	LikedByMe	*bool		`json:"liked_by_me,omitempty"`
+	RepostOfID	uuid.NullUUID	`json:"repost_of_id"`
+	QuoteOfID	uuid.NullUUID	`json:"quote_of_id"`
+	Original	*Chirp		`json:"original,omitempty"`
+}
+
+type Thread struct {
+	Ancestors	[]Chirp		`json:"ancestors"`
+	Chirp		Chirp		`json:"chirp"`
+	Replies		[]Chirp		`json:"replies"`
+	NextCursor	*string		`json:"next_cursor"`
 }
 
 func (cfg *apiConfig) handlerPostChirp(w http.ResponseWriter, r *http.Request) {
 	type ChirpReq struct {
-		Body   string    `json:"body"`
+		Body    string     `json:"body"`
+		ReplyTo *uuid.UUID `json:"reply_to"`
+		QuoteOf *uuid.UUID `json:"quote_of"`
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
+	userID, err := cfg.authenticatedUserIDWithScope(r, scopeChirpsWrite)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid token")
-		return
-	}
-	userID, err := auth.ValidateJWT(token, cfg.jwt_secret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		respondWithAuthError(w, err)
 		return
 	}
 
@@ -59,39 +68,237 @@ func (cfg *apiConfig) handlerPostChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		Body:      cleanedBody,
-		UserID:    user.ID,
+	replyToID := uuid.NullUUID{}
+	if params.ReplyTo != nil {
+		parent, err := cfg.db.GetOneChirp(r.Context(), database.GetOneChirpParams{
+			ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+			ID:       *params.ReplyTo,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusNotFound, "chirp being replied to not found")
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "error retrieving chirp being replied to")
+			log.Printf("error retrieving parent chirp %s while posting chirp: %v", *params.ReplyTo, err)
+			return
+		}
+		replyToID = uuid.NullUUID{UUID: parent.ChirpListing.ID, Valid: true}
+	}
+
+	quoteOfID := uuid.NullUUID{}
+	var quoted *Chirp
+	if params.QuoteOf != nil {
+		original, ok := cfg.requireReferencedChirp(w, r, userID, *params.QuoteOf, "quoted")
+		if !ok {
+			return
+		}
+		quoteOfID = uuid.NullUUID{UUID: original.ID, Valid: true}
+		quoted = &original
+	}
+
+	var chirp database.Chirp
+	err = cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		chirp, err = q.CreateChirp(r.Context(), database.CreateChirpParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			Body:      cleanedBody,
+			UserID:    user.ID,
+			ReplyToID: replyToID,
+			QuoteOfID: quoteOfID,
+		})
+		if err != nil {
+			return err
+		}
+		err = saveChirpHashtags(r.Context(), q, chirp)
+		if err != nil {
+			return err
+		}
+		return saveChirpMentions(r.Context(), q, chirp)
 	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "error creating chirp")
 		log.Printf("error inserting chirp into db while posting chirp: %v", err)
 		return
 	}
-	
-	respondWithJSON(w, http.StatusCreated, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+
+	result := databaseChirpToChirp(chirp)
+	result.Original = quoted
+	cfg.publishChirp(result)
+	respondWithJSON(w, http.StatusCreated, result)
+}
+
+func (cfg *apiConfig) handlerRepostChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	chirpID := r.PathValue("id")
+	uid, err := uuid.Parse(chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp ID")
+		log.Printf("error parsing chirp ID: %s while reposting chirp. Error: %s", chirpID, err)
+		return
+	}
+
+	original, ok := cfg.requireReferencedChirp(w, r, userID, uid, "reposted")
+	if !ok {
+		return
+	}
+
+	// Reposts carry no body of their own, so they skip validateAndCleanChirp.
+	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+		Body:       "",
+		UserID:     userID,
+		RepostOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
 	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "chirp already reposted")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error reposting chirp")
+		log.Printf("error inserting repost of chirp %s by user %s: %v", original.ID, userID, err)
+		return
+	}
+
+	result := databaseChirpToChirp(chirp)
+	result.Original = &original
+	cfg.publishChirp(result)
+	respondWithJSON(w, http.StatusCreated, result)
+}
+
+func (cfg *apiConfig) handlerUndoRepost(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	chirpID := r.PathValue("id")
+	uid, err := uuid.Parse(chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp ID")
+		log.Printf("error parsing chirp ID: %s while undoing repost. Error: %s", chirpID, err)
+		return
+	}
+
+	// The ID may be that of another repost of the same chirp, which is how
+	// requireReferencedChirp resolved it when reposting.
+	repost, err := cfg.db.DeleteRepost(r.Context(), database.DeleteRepostParams{
+		UserID:  userID,
+		ChirpID: uid,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "chirp not reposted")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error undoing repost")
+		log.Printf("error deleting repost of chirp %s by user %s: %v", chirpID, userID, err)
+		return
+	}
+
+	cfg.publishChirpDeleted(Chirp{
+		ID:         repost.ID,
+		CreatedAt:  repost.CreatedAt,
+		UpdatedAt:  repost.UpdatedAt,
+		UserID:     repost.UserID,
+		RepostOfID: repost.RepostOfID,
+		Original: &Chirp{
+			ID:     repost.RepostOfID.UUID,
+			UserID: repost.OriginalUserID,
+		},
+	})
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (cfg *apiConfig) handlerGetAllChirps(w http.ResponseWriter, r *http.Request) {
-	chirps, err := cfg.db.GetAllChirps(r.Context())
+	viewerID, err := cfg.optionalUserID(r, scopeChirpsRead)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "error retrieving chirps")
-		log.Printf("error retrieving chirps table: %s", err)
+		respondWithAuthError(w, err)
+		return
+	}
+
+	authorID := uuid.NullUUID{}
+	if rawAuthorID := r.URL.Query().Get("author_id"); rawAuthorID != "" {
+		uid, err := uuid.Parse(rawAuthorID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid author ID")
+			return
+		}
+		authorID = uuid.NullUUID{UUID: uid, Valid: true}
 	}
 
-	respondWithJSON(w, http.StatusOK, chirps)
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = "asc"
+	}
+	if sort != "asc" && sort != "desc" {
+		respondWithError(w, http.StatusBadRequest, "sort must be either asc or desc")
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Each order has its own query so that both can walk the (created_at, id)
+	// index.
+	var chirps []Chirp
+	if sort == "desc" {
+		rows, err := cfg.db.GetAllChirpsDesc(r.Context(), database.GetAllChirpsDescParams{
+			ViewerID:        viewerID,
+			AuthorID:        authorID,
+			BeforeCreatedAt: page.before().Time,
+			BeforeID:        page.before().ID,
+			PageSize:        page.fetchSize(),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error retrieving chirps")
+			log.Printf("error retrieving chirps table: %s", err)
+			return
+		}
+		chirps = make([]Chirp, len(rows))
+		for i, row := range rows {
+			chirps[i] = databaseChirpRowToChirp(chirpRow(row), viewerID)
+		}
+	} else {
+		rows, err := cfg.db.GetAllChirps(r.Context(), database.GetAllChirpsParams{
+			ViewerID:       viewerID,
+			AuthorID:       authorID,
+			AfterCreatedAt: page.after().Time,
+			AfterID:        page.after().ID,
+			PageSize:       page.fetchSize(),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error retrieving chirps")
+			log.Printf("error retrieving chirps table: %s", err)
+			return
+		}
+		chirps = make([]Chirp, len(rows))
+		for i, row := range rows {
+			chirps[i] = databaseChirpRowToChirp(chirpRow(row), viewerID)
+		}
+	}
+	respondWithJSON(w, http.StatusOK, paginate(w, r, page, chirps, chirpCursor))
 }
 
 func (cfg *apiConfig) handlerGetOneChirp(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.optionalUserID(r, scopeChirpsRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
 	chirpID := r.PathValue("id")
 	uid, err := uuid.Parse(chirpID)
 	if err != nil {
@@ -100,7 +307,10 @@ func (cfg *apiConfig) handlerGetOneChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	chirp, err := cfg.db.GetOneChirp(r.Context(), uid)
+	row, err := cfg.db.GetOneChirp(r.Context(), database.GetOneChirpParams{
+		ViewerID: viewerID,
+		ID:       uid,
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			respondWithError(w, http.StatusNotFound, "chirp not found")
@@ -112,13 +322,244 @@ func (cfg *apiConfig) handlerGetOneChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, Chirp{
-		ID: chirp.ID,
+	chirp := databaseChirpRowToChirp(chirpRow(row), viewerID)
+	err = cfg.recordChirpRead(r.Context(), chirp, viewerID)
+	if err != nil {
+		log.Printf("error recording read of chirp %s: %v", chirpID, err)
+	}
+
+	respondWithJSON(w, http.StatusOK, chirp)
+}
+
+func (cfg *apiConfig) handlerGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.optionalUserID(r, scopeChirpsRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	chirpID := r.PathValue("id")
+	uid, err := uuid.Parse(chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp ID")
+		log.Printf("error parsing chirp ID: %s while retrieving thread. Error: %s", chirpID, err)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	chirp, err := cfg.db.GetOneChirp(r.Context(), database.GetOneChirpParams{
+		ViewerID: viewerID,
+		ID:       uid,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "chirp not found")
+			return
+		}
+
+		respondWithError(w, http.StatusInternalServerError, "error retrieving chirp by id")
+		log.Printf("error retrieving chirp by id %s while retrieving thread: %v", chirpID, err)
+		return
+	}
+
+	ancestorRows, err := cfg.db.GetChirpAncestors(r.Context(), database.GetChirpAncestorsParams{
+		ID:       chirp.ChirpListing.ID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving thread")
+		log.Printf("error retrieving ancestors of chirp %s: %v", chirpID, err)
+		return
+	}
+
+	replyRows, err := cfg.db.GetChirpReplies(r.Context(), database.GetChirpRepliesParams{
+		ViewerID:       viewerID,
+		ChirpID:        uuid.NullUUID{UUID: chirp.ChirpListing.ID, Valid: true},
+		AfterCreatedAt: page.after().Time,
+		AfterID:        page.after().ID,
+		PageSize:       page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving thread")
+		log.Printf("error retrieving replies to chirp %s: %v", chirpID, err)
+		return
+	}
+
+	ancestors := make([]Chirp, len(ancestorRows))
+	for i, row := range ancestorRows {
+		ancestors[i] = databaseChirpRowToChirp(chirpRow(row), viewerID)
+	}
+	replies := make([]Chirp, len(replyRows))
+	for i, row := range replyRows {
+		replies[i] = databaseChirpRowToChirp(chirpRow(row), viewerID)
+	}
+	repliesPage := paginate(w, r, page, replies, chirpCursor)
+
+	respondWithJSON(w, http.StatusOK, Thread{
+		Ancestors:  ancestors,
+		Chirp:      databaseChirpRowToChirp(chirpRow(chirp), viewerID),
+		Replies:    repliesPage.Items,
+		NextCursor: repliesPage.NextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserIDWithScope(r, scopeChirpsWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	// Authors can delete their chirps whoever blocked them since, so blocks
+	// are not checked.
+	chirp, ok := cfg.requireTargetChirp(w, r, uuid.NullUUID{})
+	if !ok {
+		return
+	}
+
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "you can only delete your own chirps")
+		return
+	}
+
+	// Reposts would go with the chirp anyway, but deleting them first tells
+	// which ones to announce.
+	var reposts []database.Chirp
+	err = cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		reposts, err = q.DeleteRepostsOf(r.Context(), uuid.NullUUID{UUID: chirp.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		return q.DeleteChirp(r.Context(), chirp.ID)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error deleting chirp")
+		log.Printf("error deleting chirp %s: %v", chirp.ID, err)
+		return
+	}
+
+	for _, repost := range reposts {
+		deleted := databaseChirpToChirp(repost)
+		deleted.Original = &chirp
+		cfg.publishChirpDeleted(deleted)
+	}
+	cfg.publishChirpDeleted(chirp)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func databaseChirpToChirp(chirp database.Chirp) Chirp {
+	return Chirp{
+		ID:        chirp.ID,
 		CreatedAt: chirp.CreatedAt,
 		UpdatedAt: chirp.UpdatedAt,
-		Body: chirp.Body,
-		UserID: chirp.UserID,
+		Body:      chirp.Body,
+		UserID:    chirp.UserID,
+		ReplyToID:  chirp.ReplyToID,
+		RepostOfID: chirp.RepostOfID,
+		QuoteOfID:  chirp.QuoteOfID,
+	}
+}
+
+// chirpRow has the shape shared by every query returning chirps for display,
+// so their generated row types can all be converted to it.
+type chirpRow struct {
+	ChirpListing database.ChirpListing
+	LikedByMe    bool
+}
+
+// databaseChirpRowToChirp adds the like counters and the reposted or quoted
+// chirp computed by the chirp queries. liked_by_me is only reported to
+// authenticated viewers.
+func databaseChirpRowToChirp(row chirpRow, viewerID uuid.NullUUID) Chirp {
+	listing := row.ChirpListing
+	result := Chirp{
+		ID:         listing.ID,
+		CreatedAt:  listing.CreatedAt,
+		UpdatedAt:  listing.UpdatedAt,
+		Body:       listing.Body,
+		UserID:     listing.UserID,
+		ReplyToID:  listing.ReplyToID,
+		LikeCount:  listing.LikeCount,
+		RepostOfID: listing.RepostOfID,
+		QuoteOfID:  listing.QuoteOfID,
+	}
+	if viewerID.Valid {
+		result.LikedByMe = &row.LikedByMe
+	}
+	if listing.OriginalID.Valid {
+		result.Original = &Chirp{
+			ID:        listing.OriginalID.UUID,
+			CreatedAt: listing.OriginalCreatedAt.Time,
+			UpdatedAt: listing.OriginalUpdatedAt.Time,
+			Body:      listing.OriginalBody.String,
+			UserID:    listing.OriginalUserID.UUID,
+			LikeCount: listing.OriginalLikeCount,
+		}
+	}
+	return result
+}
+
+// requireTargetChirp resolves the {id} path value to an existing chirp, writing
+// the error response itself when it cannot. Chirps involving a user blocking
+// or blocked by viewerID are reported as not found.
+func (cfg *apiConfig) requireTargetChirp(w http.ResponseWriter, r *http.Request, viewerID uuid.NullUUID) (Chirp, bool) {
+	chirpID := r.PathValue("id")
+	uid, err := uuid.Parse(chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp ID")
+		return Chirp{}, false
+	}
+
+	row, err := cfg.db.GetOneChirp(r.Context(), database.GetOneChirpParams{
+		ViewerID: viewerID,
+		ID:       uid,
 	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "chirp not found")
+			return Chirp{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving chirp by id")
+		log.Printf("error retrieving chirp by id %s: %v", chirpID, err)
+		return Chirp{}, false
+	}
+
+	return databaseChirpRowToChirp(chirpRow(row), uuid.NullUUID{}), true
+}
+
+// requireReferencedChirp loads the chirp a new repost or quote by userID
+// points at. Referencing a repost points at the chirp it reposted instead, so
+// chains never form.
+func (cfg *apiConfig) requireReferencedChirp(w http.ResponseWriter, r *http.Request, userID uuid.UUID, chirpID uuid.UUID, kind string) (Chirp, bool) {
+	row, err := cfg.db.GetOneChirp(r.Context(), database.GetOneChirpParams{
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+		ID:       chirpID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, kind+" chirp not found")
+			return Chirp{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving "+kind+" chirp")
+		log.Printf("error retrieving %s chirp %s: %v", kind, chirpID, err)
+		return Chirp{}, false
+	}
+
+	chirp := databaseChirpRowToChirp(chirpRow(row), uuid.NullUUID{})
+	if chirp.RepostOfID.Valid && chirp.Original != nil {
+		return *chirp.Original, true
+	}
+	chirp.Original = nil
+	return chirp, true
+}
+
+func chirpCursor(chirp Chirp) pageCursor {
+	return pageCursor{Time: chirp.CreatedAt, ID: chirp.ID}
 }
 
 func validateAndCleanChirp(body string, bannedWords map[string]struct{}) (string, error) {
@@ -129,11 +570,16 @@ func validateAndCleanChirp(body string, bannedWords map[string]struct{}) (string
 		return "", fmt.Errorf("chirp is too long")
 	}
 
+	return maskBannedWords(body, bannedWords), nil
+}
+
+// maskBannedWords replaces every banned word in body with "****".
+func maskBannedWords(body string, bannedWords map[string]struct{}) string {
 	words := strings.Split(body, " ")
 	for i, word := range words {
 		if _, ok := bannedWords[strings.ToLower(word)]; ok {
 			words[i] = "****"
 		}
 	}
-	return strings.Join(words, " "), nil
+	return strings.Join(words, " ")
 }
\ No newline at end of file
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/leonardomlouzas/GOose/internal/auth"
+	"github.com/leonardomlouzas/GOose/internal/database"
+	"github.com/leonardomlouzas/GOose/internal/mailer"
+)
+
+// Purposes of email tokens. A token only works for the purpose it was
+// issued for.
+const (
+	emailTokenPasswordReset = "password_reset"
+	emailTokenVerification  = "email_verification"
+)
+
+const passwordResetTokenDuration = time.Hour
+const emailVerificationTokenDuration = 48 * time.Hour
+const mailSendTimeout = 30 * time.Second
+
+var errEmailChanged = errors.New("email changed")
+
+// handlerRequestPasswordReset emails a reset token. It answers the same way
+// whether or not the address belongs to an account, so it cannot be used to
+// find out who is registered.
+func (cfg *apiConfig) handlerRequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email	string	`json:"email"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	email := strings.TrimSpace(params.Email)
+	if email == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := cfg.db.GetUserByEmail(r.Context(), email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		log.Printf("error retrieving user by email while requesting password reset: %v", err)
+		return
+	}
+
+	token, err := cfg.createEmailToken(r.Context(), user, emailTokenPasswordReset, passwordResetTokenDuration)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error creating reset token")
+		log.Printf("error creating password reset token for user %s: %v", user.ID, err)
+		return
+	}
+
+	cfg.sendEmail(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your Chirpy account.\n\n"+
+			"Your reset token is:\n\n%s\n\n"+
+			"It expires in %s. If you did not ask for this, you can ignore this email.\n",
+			token, passwordResetTokenDuration),
+	})
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handlerConfirmPasswordReset sets a new password with a reset token. Every
+// session of the user is logged out and every API key revoked. Like
+// verification links, a token is only good while the account still has the
+// address it was sent to.
+func (cfg *apiConfig) handlerConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token		string	`json:"token"`
+		Password	string	`json:"password"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	password := strings.TrimSpace(params.Password)
+	if params.Token == "" || password == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid password")
+		log.Printf("error while hashing password. Error: %s", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	err = cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		emailToken, err := q.UseEmailToken(r.Context(), database.UseEmailTokenParams{
+			TokenHash: auth.HashToken(params.Token),
+			Purpose:   emailTokenPasswordReset,
+			UsedAt:    sql.NullTime{Time: now, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		user, err := q.GetUserById(r.Context(), emailToken.UserID)
+		if err != nil {
+			return err
+		}
+		if user.Email != emailToken.Email {
+			return errEmailChanged
+		}
+		err = q.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+			ID:             emailToken.UserID,
+			HashedPassword: hashedPassword,
+			UpdatedAt:      now,
+		})
+		if err != nil {
+			return err
+		}
+		err = q.InvalidateEmailTokens(r.Context(), database.InvalidateEmailTokensParams{
+			UserID:  emailToken.UserID,
+			Purpose: emailTokenPasswordReset,
+			UsedAt:  sql.NullTime{Time: now, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		err = q.RevokeAllRefreshTokensForUser(r.Context(), database.RevokeAllRefreshTokensForUserParams{
+			UserID:    emailToken.UserID,
+			RevokedAt: sql.NullTime{Time: now, Valid: true},
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		return q.RevokeAllAPIKeysForUser(r.Context(), database.RevokeAllAPIKeysForUserParams{
+			UserID:    emailToken.UserID,
+			RevokedAt: sql.NullTime{Time: now, Valid: true},
+		})
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusBadRequest, "invalid or expired token")
+			return
+		}
+		if err == errEmailChanged {
+			respondWithError(w, http.StatusBadRequest, "the email address changed since this token was sent")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error resetting password")
+		log.Printf("error resetting password: %v", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerVerifyEmail serves the link sent by sendVerificationEmail. The link
+// only verifies the address it was sent to.
+func (cfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, http.StatusBadRequest, "missing token")
+		return
+	}
+
+	now := time.Now().UTC()
+	err := cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		emailToken, err := q.UseEmailToken(r.Context(), database.UseEmailTokenParams{
+			TokenHash: auth.HashToken(token),
+			Purpose:   emailTokenVerification,
+			UsedAt:    sql.NullTime{Time: now, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		verified, err := q.MarkEmailVerified(r.Context(), database.MarkEmailVerifiedParams{
+			ID:              emailToken.UserID,
+			Email:           emailToken.Email,
+			EmailVerifiedAt: sql.NullTime{Time: now, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if verified == 0 {
+			return errEmailChanged
+		}
+		return nil
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusBadRequest, "invalid or expired token")
+			return
+		}
+		if err == errEmailChanged {
+			respondWithError(w, http.StatusBadRequest, "the email address changed since this link was sent")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error verifying email")
+		log.Printf("error verifying email: %v", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, struct {
+		Message string `json:"message"`
+	}{Message: "email verified"})
+}
+
+func (cfg *apiConfig) handlerResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		log.Printf("error retrieving user %s while resending verification email: %v", userID, err)
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusConflict, "email already verified")
+		return
+	}
+
+	if err := cfg.sendVerificationEmail(r.Context(), user); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error sending verification email")
+		log.Printf("error sending verification email to user %s: %v", userID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (cfg *apiConfig) sendVerificationEmail(ctx context.Context, user database.User) error {
+	token, err := cfg.createEmailToken(ctx, user, emailTokenVerification, emailVerificationTokenDuration)
+	if err != nil {
+		return err
+	}
+
+	link := cfg.publicURL + "/api/verify-email?token=" + url.QueryEscape(token)
+	cfg.sendEmail(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Chirpy email address",
+		Body: fmt.Sprintf("Open this link to confirm that %s is your email address:\n\n%s\n\n"+
+			"It expires in %s.\n",
+			user.Email, link, emailVerificationTokenDuration),
+	})
+	return nil
+}
+
+// createEmailToken stores a new token for user's current address and returns
+// it. Only its hash is kept.
+func (cfg *apiConfig) createEmailToken(ctx context.Context, user database.User, purpose string, expiresIn time.Duration) (string, error) {
+	token, err := auth.MakeRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = cfg.db.CreateEmailToken(ctx, database.CreateEmailTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: time.Now().UTC().Add(expiresIn),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sendEmail sends message in the background so that slow mail servers do not
+// hold up requests, nor reveal through timing whether an address is known.
+func (cfg *apiConfig) sendEmail(message mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if err := cfg.mailer.Send(ctx, message); err != nil {
+			log.Printf("error sending email %q to %s: %v", message.Subject, message.To, err)
+		}
+	}()
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/leonardomlouzas/GOose/internal/auth"
+)
+
+// requestPasswordReset asks for a reset token to be mailed to email.
+func requestPasswordReset(t *testing.T, cfg *apiConfig, email string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body := strings.NewReader(fmt.Sprintf(`{"email": %q}`, email))
+	w := httptest.NewRecorder()
+	cfg.handlerRequestPasswordReset(w, httptest.NewRequest(http.MethodPost, "/api/password-reset/request", body))
+	return w
+}
+
+// resetTokenFrom extracts the token from a password reset email.
+func resetTokenFrom(t *testing.T, body string) string {
+	t.Helper()
+
+	_, rest, ok := strings.Cut(body, "Your reset token is:\n\n")
+	if !ok {
+		t.Fatalf("no reset token in email %q", body)
+	}
+	token, _, _ := strings.Cut(rest, "\n")
+	return token
+}
+
+func confirmPasswordReset(t *testing.T, cfg *apiConfig, token, password string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body := strings.NewReader(fmt.Sprintf(`{"token": %q, "password": %q}`, token, password))
+	w := httptest.NewRecorder()
+	cfg.handlerConfirmPasswordReset(w, httptest.NewRequest(http.MethodPost, "/api/password-reset/confirm", body))
+	return w
+}
+
+func TestPasswordReset(t *testing.T) {
+	cfg, mail := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com", "old password")
+
+	if w := requestPasswordReset(t, cfg, "walt@example.com"); w.Code != http.StatusAccepted {
+		t.Fatalf("request status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	message := waitForEmail(t, mail, 1)
+	if message.To != "walt@example.com" {
+		t.Errorf("email sent to %q", message.To)
+	}
+	token := resetTokenFrom(t, message.Body)
+
+	if w := confirmPasswordReset(t, cfg, token, "new password"); w.Code != http.StatusNoContent {
+		t.Fatalf("confirm status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body)
+	}
+	updated, err := cfg.db.GetUserById(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	if auth.CheckPasswordHash("new password", updated.HashedPassword) != nil {
+		t.Error("password was not changed")
+	}
+
+	if w := confirmPasswordReset(t, cfg, token, "third password"); w.Code != http.StatusBadRequest {
+		t.Errorf("reusing the token: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestPasswordResetUnknownEmail(t *testing.T) {
+	cfg, mail := newTestConfig(t)
+
+	if w := requestPasswordReset(t, cfg, "nobody@example.com"); w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	if messages := mail.Messages(); len(messages) != 0 {
+		t.Errorf("sent %d emails for an unknown address", len(messages))
+	}
+}
+
+func TestPasswordResetAfterEmailChange(t *testing.T) {
+	cfg, mail := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com", "old password")
+
+	requestPasswordReset(t, cfg, "walt@example.com")
+	token := resetTokenFrom(t, waitForEmail(t, mail, 1).Body)
+
+	r := httptest.NewRequest(http.MethodPut, "/api/users", strings.NewReader(`{"email": "heisenberg@example.com"}`))
+	r.Header.Set("Authorization", accessToken(t, cfg, user.ID))
+	w := httptest.NewRecorder()
+	cfg.handlerUpdateUser(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	if w := confirmPasswordReset(t, cfg, token, "new password"); w.Code != http.StatusBadRequest {
+		t.Errorf("confirm status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	updated, err := cfg.db.GetUserById(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	if auth.CheckPasswordHash("old password", updated.HashedPassword) != nil {
+		t.Error("a token sent to the old address changed the password")
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	cfg, mail := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com", "password")
+
+	if err := cfg.sendVerificationEmail(context.Background(), user); err != nil {
+		t.Fatalf("sendVerificationEmail: %v", err)
+	}
+	link := verificationLinkFrom(t, waitForEmail(t, mail, 1).Body)
+
+	w := httptest.NewRecorder()
+	cfg.handlerVerifyEmail(w, httptest.NewRequest(http.MethodGet, link, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	updated, err := cfg.db.GetUserById(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	if !updated.EmailVerifiedAt.Valid {
+		t.Error("email was not marked as verified")
+	}
+
+	w = httptest.NewRecorder()
+	cfg.handlerVerifyEmail(w, httptest.NewRequest(http.MethodGet, link, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("reusing the link: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestVerifyEmailAfterEmailChange(t *testing.T) {
+	cfg, mail := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com", "password")
+
+	if err := cfg.sendVerificationEmail(context.Background(), user); err != nil {
+		t.Fatalf("sendVerificationEmail: %v", err)
+	}
+	link := verificationLinkFrom(t, waitForEmail(t, mail, 1).Body)
+
+	r := httptest.NewRequest(http.MethodPut, "/api/users", strings.NewReader(`{"email": "heisenberg@example.com"}`))
+	r.Header.Set("Authorization", accessToken(t, cfg, user.ID))
+	w := httptest.NewRecorder()
+	cfg.handlerUpdateUser(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if message := waitForEmail(t, mail, 2); message.To != "heisenberg@example.com" {
+		t.Errorf("new verification email sent to %q", message.To)
+	}
+
+	w = httptest.NewRecorder()
+	cfg.handlerVerifyEmail(w, httptest.NewRequest(http.MethodGet, link, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	updated, err := cfg.db.GetUserById(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	if updated.EmailVerifiedAt.Valid {
+		t.Error("a link sent to the old address verified the new one")
+	}
+}
+
+// verificationLinkFrom extracts the path and query of the link in a
+// verification email.
+func verificationLinkFrom(t *testing.T, body string) string {
+	t.Helper()
+
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "http://chirpy.test/api/verify-email?") {
+			link, err := url.Parse(line)
+			if err != nil {
+				t.Fatalf("parsing link: %v", err)
+			}
+			return link.RequestURI()
+		}
+	}
+	t.Fatalf("no verification link in email %q", body)
+	return ""
+}
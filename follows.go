@@ -0,0 +1,197 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+type FollowedUser struct {
+	User
+	FollowedAt	time.Time	`json:"followed_at"`
+}
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	followerID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	followeeID, ok := cfg.requireTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	if followerID == followeeID {
+		respondWithError(w, http.StatusBadRequest, "you cannot follow yourself")
+		return
+	}
+
+	blocked, err := cfg.isBlockedBetween(r.Context(), uuid.NullUUID{UUID: followerID, Valid: true}, followeeID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error following user")
+		log.Printf("error checking blocks between %s and %s: %v", followerID, followeeID, err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "you cannot follow this user")
+		return
+	}
+
+	err = cfg.db.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+		CreatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "already following this user")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error following user")
+		log.Printf("error inserting follow %s -> %s: %v", followerID, followeeID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followerID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	followeeID, ok := cfg.requireTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error unfollowing user")
+		log.Printf("error deleting follow %s -> %s: %v", followerID, followeeID, err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "not following this user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	if _, err := cfg.authenticatedUserID(r); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	userID, ok := cfg.requireTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := cfg.db.GetFollowers(r.Context(), database.GetFollowersParams{
+		UserID:           userID,
+		BeforeFollowedAt: page.before().Time,
+		BeforeID:         page.before().ID,
+		PageSize:         page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving followers")
+		log.Printf("error retrieving followers of user %s: %v", userID, err)
+		return
+	}
+
+	followers := make([]FollowedUser, len(rows))
+	for i, row := range rows {
+		followers[i] = FollowedUser{
+			User:       databaseUserToUser(row.User),
+			FollowedAt: row.FollowedAt,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, paginate(w, r, page, followers, followedUserCursor))
+}
+
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	if _, err := cfg.authenticatedUserID(r); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	userID, ok := cfg.requireTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := cfg.db.GetFollowing(r.Context(), database.GetFollowingParams{
+		UserID:           userID,
+		BeforeFollowedAt: page.before().Time,
+		BeforeID:         page.before().ID,
+		PageSize:         page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving followed users")
+		log.Printf("error retrieving users followed by %s: %v", userID, err)
+		return
+	}
+
+	following := make([]FollowedUser, len(rows))
+	for i, row := range rows {
+		following[i] = FollowedUser{
+			User:       databaseUserToUser(row.User),
+			FollowedAt: row.FollowedAt,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, paginate(w, r, page, following, followedUserCursor))
+}
+
+// requireTargetUser resolves the {id} path value to an existing user, writing
+// the error response itself when it cannot.
+func (cfg *apiConfig) requireTargetUser(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID := r.PathValue("id")
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user ID")
+		return uuid.Nil, false
+	}
+
+	_, err = cfg.db.GetUserById(r.Context(), uid)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "user not found")
+			return uuid.Nil, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		log.Printf("error retrieving user by id: %s. Error: %v", userID, err)
+		return uuid.Nil, false
+	}
+
+	return uid, true
+}
+
+func followedUserCursor(user FollowedUser) pageCursor {
+	return pageCursor{Time: user.FollowedAt, ID: user.ID}
+}
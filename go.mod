@@ -3,6 +3,7 @@ module github.com/leonardomlouzas/GOose
 go 1.23.2
 
 require (
+	github.com/coder/websocket v1.8.15 // indirect
 	github.com/golang-jwt/jwt/v5 v5.2.2 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/joho/godotenv v1.5.1 // indirect
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+const maxHashtagLength = 50
+const defaultTrendingWindow = 24 * time.Hour
+const maxTrendingWindow = 30 * 24 * time.Hour
+
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])#([\p{L}\p{N}_]+)`)
+
+type TrendingHashtag struct {
+	Tag			string	`json:"tag"`
+	UsageCount	int64	`json:"usage_count"`
+}
+
+func (cfg *apiConfig) handlerGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.optionalUserID(r, scopeChirpsRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	tag := normalizeHashtag(r.PathValue("tag"))
+	if tag == "" || len(tag) > maxHashtagLength {
+		respondWithError(w, http.StatusBadRequest, "invalid hashtag")
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := cfg.db.GetHashtagChirps(r.Context(), database.GetHashtagChirpsParams{
+		ViewerID:        viewerID,
+		Tag:             tag,
+		BeforeCreatedAt: page.before().Time,
+		BeforeID:        page.before().ID,
+		PageSize:        page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving hashtag chirps")
+		log.Printf("error retrieving chirps tagged #%s: %v", tag, err)
+		return
+	}
+
+	chirps := make([]Chirp, len(rows))
+	for i, row := range rows {
+		chirps[i] = databaseChirpRowToChirp(chirpRow(row), viewerID)
+	}
+	respondWithJSON(w, http.StatusOK, paginate(w, r, page, chirps, chirpCursor))
+}
+
+func (cfg *apiConfig) handlerGetTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	if rawWindow := r.URL.Query().Get("window"); rawWindow != "" {
+		parsedWindow, err := time.ParseDuration(rawWindow)
+		if err != nil || parsedWindow <= 0 || parsedWindow > maxTrendingWindow {
+			respondWithError(w, http.StatusBadRequest, "window must be a duration between 0 and 720h")
+			return
+		}
+		window = parsedWindow
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := cfg.db.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+		Since:    time.Now().UTC().Add(-window),
+		TagLimit: limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving trending hashtags")
+		log.Printf("error retrieving trending hashtags: %v", err)
+		return
+	}
+
+	hashtags := make([]TrendingHashtag, len(rows))
+	for i, row := range rows {
+		hashtags[i] = TrendingHashtag{
+			Tag:        row.Tag,
+			UsageCount: row.UsageCount,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, hashtags)
+}
+
+// saveChirpHashtags links a freshly created chirp to the hashtags in its body.
+// It runs in the transaction that creates the chirp.
+func saveChirpHashtags(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	for _, tag := range extractHashtags(chirp.Body) {
+		hashtag, err := q.UpsertHashtag(ctx, database.UpsertHashtagParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			Tag:       tag,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.AddChirpHashtag(ctx, database.AddChirpHashtagParams{
+			ChirpID:   chirp.ID,
+			HashtagID: hashtag.ID,
+			CreatedAt: chirp.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractHashtags returns the distinct, normalized #tags found in body.
+func extractHashtags(body string) []string {
+	seen := make(map[string]struct{})
+	tags := []string{}
+	for _, match := range hashtagPattern.FindAllStringSubmatch(body, -1) {
+		tag := normalizeHashtag(match[1])
+		if len(tag) > maxHashtagLength {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+func normalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
@@ -2,9 +2,11 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -28,38 +30,123 @@ func CheckPasswordHash(password, hash string) error {
 	return nil
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer: "chirpy",
-		IssuedAt: jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject: userID.String(),
-	})
+// challengeAudience marks tokens proving only the password step of a two
+// factor login. They are not access tokens.
+const challengeAudience = "chirpy-2fa"
 
-	return token.SignedString([]byte(tokenSecret))
+// accessClaims are the claims of an access token. SessionID names the refresh
+// token family the token was issued for, if any.
+type accessClaims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid,omitempty"`
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return []byte(tokenSecret), nil
-	})
+// MakeJWT returns an access token for userID. sessionID is the session it is
+// issued to, or uuid.Nil when there is none.
+func MakeJWT(userID, sessionID uuid.UUID, keyring *Keyring, expiresIn time.Duration) (string, error) {
+	claims := accessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer: "chirpy",
+			IssuedAt: jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+			Subject: userID.String(),
+		},
+	}
+	if sessionID != uuid.Nil {
+		claims.SessionID = sessionID.String()
+	}
+	return keyring.sign(claims)
+}
+
+func ValidateJWT(tokenString string, keyring *Keyring) (uuid.UUID, error) {
+	userID, _, err := ValidateJWTWithExpiry(tokenString, keyring)
+	return userID, err
+}
+
+// ValidateJWTWithSession is ValidateJWT that also returns the session the
+// token was issued to, or uuid.Nil for tokens issued outside of one.
+func ValidateJWTWithSession(tokenString string, keyring *Keyring) (uuid.UUID, uuid.UUID, error) {
+	claims, err := parseAccessToken(tokenString, keyring)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	if claims.SessionID == "" {
+		return userID, uuid.Nil, nil
+	}
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return userID, sessionID, nil
+}
+
+// ValidateJWTWithExpiry is ValidateJWT for long-lived connections that have to
+// know when the token stops being valid.
+func ValidateJWTWithExpiry(tokenString string, keyring *Keyring) (uuid.UUID, time.Time, error) {
+	claims, err := parseAccessToken(tokenString, keyring)
+	if err != nil {
+		return uuid.Nil, time.Time{}, err
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, time.Time{}, err
+	}
+	return userID, claims.ExpiresAt.Time, nil
+}
+
+func parseAccessToken(tokenString string, keyring *Keyring) (*accessClaims, error) {
+	claims := &accessClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, keyring.keyFunc, jwt.WithExpirationRequired())
 	if err != nil {
-		return uuid.Nil, err
+		return nil, err
+	}
+
+	// Access tokens have no audience; anything else, such as a two factor
+	// challenge, must not be usable in their place.
+	if len(claims.Audience) > 0 {
+		return nil, fmt.Errorf("unexpected audience %v", claims.Audience)
 	}
+	return claims, nil
+}
+
+// MakeChallengeJWT returns a token for a user who passed the password step of
+// a login but still has to send a second factor. challengeID identifies the
+// challenge, so that the codes tried against it can be counted.
+func MakeChallengeJWT(userID, challengeID uuid.UUID, keyring *Keyring, expiresIn time.Duration) (string, error) {
+	return keyring.sign(jwt.RegisteredClaims{
+		Issuer:    "chirpy",
+		Audience:  jwt.ClaimStrings{challengeAudience},
+		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+		Subject:   userID.String(),
+		ID:        challengeID.String(),
+	})
+}
 
-	subject, err := token.Claims.GetSubject()
+// ValidateChallengeJWT returns the user a challenge token was issued to and
+// the challenge's ID. Access tokens are rejected.
+func ValidateChallengeJWT(tokenString string, keyring *Keyring) (uuid.UUID, uuid.UUID, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, keyring.keyFunc, jwt.WithExpirationRequired(), jwt.WithAudience(challengeAudience))
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, uuid.Nil, err
 	}
 
-	userID, err := uuid.Parse(subject)
+	userID, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, uuid.Nil, err
 	}
-	return userID, nil
+	challengeID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return userID, challengeID, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -68,7 +155,10 @@ func GetBearerToken(headers http.Header) (string, error) {
 		return "", fmt.Errorf("no authorization header")
 	}
 
-	bearerToken := authHeader[len("Bearer "):]
+	bearerToken, ok := strings.CutPrefix(authHeader, "Bearer ")
+	if !ok {
+		return "", fmt.Errorf("authorization header is not a bearer token")
+	}
 	if bearerToken == "" {
 		return "", fmt.Errorf("no bearer token")
 	}
@@ -77,10 +167,41 @@ func GetBearerToken(headers http.Header) (string, error) {
 }
 
 func MakeRefreshToken() (string, error) {
+	return MakeRandomToken()
+}
+
+// MakeRandomToken returns 256 random bits, hex encoded.
+func MakeRandomToken() (string, error) {
 	b := make([]byte, 32)
 	_, err := rand.Read(b)
 	if err != nil {
 		return "", err
 	}
 	return hex.EncodeToString(b), nil
+}
+
+// APIKeyPrefix starts every API key, so they can be told apart from JWTs and
+// spotted by secret scanners.
+const APIKeyPrefix = "chirpy_"
+
+// MakeAPIKey returns a new API key. Like other random tokens it is stored
+// hashed with HashToken.
+func MakeAPIKey() (string, error) {
+	token, err := MakeRandomToken()
+	if err != nil {
+		return "", err
+	}
+	return APIKeyPrefix + token, nil
+}
+
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// HashToken returns the form random tokens handed to users are stored in, so
+// that a leaked table cannot be used to log in. They carry enough entropy for
+// a fast hash.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
\ No newline at end of file
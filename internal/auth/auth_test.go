@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGetBearerToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{name: "bearer token", header: "Bearer abc.def", want: "abc.def"},
+		{name: "missing header", header: "", wantErr: true},
+		{name: "short header", header: "x", wantErr: true},
+		{name: "other scheme", header: "Basic dXNlcjpwYXNz", wantErr: true},
+		{name: "lowercase scheme without space", header: "bearer", wantErr: true},
+		{name: "empty token", header: "Bearer ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := http.Header{}
+			if tt.header != "" {
+				headers.Set("Authorization", tt.header)
+			}
+			got, err := GetBearerToken(headers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetBearerToken(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetBearerToken(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWTSession(t *testing.T) {
+	keyring := NewHMACKeyring("secret")
+	userID, sessionID := uuid.New(), uuid.New()
+
+	for _, want := range []uuid.UUID{sessionID, uuid.Nil} {
+		token, err := MakeJWT(userID, want, keyring, time.Hour)
+		if err != nil {
+			t.Fatalf("MakeJWT: %v", err)
+		}
+		gotUserID, gotSessionID, err := ValidateJWTWithSession(token, keyring)
+		if err != nil {
+			t.Fatalf("ValidateJWTWithSession: %v", err)
+		}
+		if gotUserID != userID || gotSessionID != want {
+			t.Errorf("ValidateJWTWithSession = %s, %s, want %s, %s", gotUserID, gotSessionID, userID, want)
+		}
+	}
+
+	challenge, err := MakeChallengeJWT(userID, uuid.New(), keyring, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeChallengeJWT: %v", err)
+	}
+	if _, _, err := ValidateJWTWithSession(challenge, keyring); err == nil {
+		t.Error("a challenge token was accepted as an access token")
+	}
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyState says what a key in a Keyring may be used for.
+type KeyState string
+
+const (
+	// KeyActive signs new tokens. A keyring has exactly one active key.
+	KeyActive KeyState = "active"
+	// KeyInactive keys are published and accepted but sign nothing: either
+	// keys about to become active or keys whose tokens have not expired yet.
+	KeyInactive KeyState = "inactive"
+	// KeyRetired keys are rejected and no longer published.
+	KeyRetired KeyState = "retired"
+)
+
+// keyringManifestFile lists the keys of a keyring directory.
+const keyringManifestFile = "keys.json"
+
+// Key is a single signing key, identified in tokens by the kid header.
+type Key struct {
+	ID    string
+	State KeyState
+
+	method     jwt.SigningMethod
+	signingKey interface{}
+	verifyKey  interface{}
+}
+
+// Keyring holds the keys used to sign and validate access tokens.
+type Keyring struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+// JWK is the public half of a key as published in a JSON Web Key Set.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type keyringManifest struct {
+	Keys []struct {
+		ID    string   `json:"kid"`
+		File  string   `json:"file"`
+		State KeyState `json:"state"`
+	} `json:"keys"`
+}
+
+// NewHMACKeyring returns a keyring signing HS256 tokens with a shared secret
+// and no kid, the way tokens were issued before keyrings existed.
+func NewHMACKeyring(secret string) *Keyring {
+	key := newHMACKey(secret, KeyActive)
+	return &Keyring{
+		active: key,
+		keys:   map[string]*Key{key.ID: key},
+	}
+}
+
+// LoadKeyring reads the keys listed in the keys.json manifest of dir. Each
+// entry names a PEM encoded RSA (RS256) or Ed25519 (EdDSA) private key file,
+// relative to dir, and its state:
+//
+//	{"keys": [{"kid": "2026-10", "file": "2026-10.pem", "state": "active"}]}
+//
+// Keys can be generated with `openssl genpkey -algorithm ed25519`. When
+// legacySecret is set, HS256 tokens without a kid signed with it are still
+// accepted so that switching to a keyring does not log everyone out.
+func LoadKeyring(dir, legacySecret string) (*Keyring, error) {
+	data, err := os.ReadFile(filepath.Join(dir, keyringManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := keyringManifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", keyringManifestFile, err)
+	}
+
+	keyring := &Keyring{keys: make(map[string]*Key)}
+	for _, entry := range manifest.Keys {
+		if entry.ID == "" {
+			return nil, fmt.Errorf("key %q has no kid", entry.File)
+		}
+		if _, ok := keyring.keys[entry.ID]; ok {
+			return nil, fmt.Errorf("duplicate kid %q", entry.ID)
+		}
+
+		key := &Key{ID: entry.ID, State: entry.State}
+		switch entry.State {
+		case KeyActive, KeyInactive:
+			err := key.loadPrivateKey(filepath.Join(dir, entry.File))
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", entry.ID, err)
+			}
+		case KeyRetired:
+			// Nothing is signed or validated with it, so the file may be gone.
+		default:
+			return nil, fmt.Errorf("key %q has unknown state %q", entry.ID, entry.State)
+		}
+
+		if key.State == KeyActive {
+			if keyring.active != nil {
+				return nil, fmt.Errorf("keys %q and %q are both active", keyring.active.ID, key.ID)
+			}
+			keyring.active = key
+		}
+		keyring.keys[key.ID] = key
+	}
+
+	if keyring.active == nil {
+		return nil, fmt.Errorf("no active key in %s", dir)
+	}
+	if legacySecret != "" {
+		key := newHMACKey(legacySecret, KeyInactive)
+		keyring.keys[key.ID] = key
+	}
+	return keyring, nil
+}
+
+// JWKS returns the public keys tokens may currently be validated with.
+// Shared secrets are never published.
+func (k *Keyring) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range k.keys {
+		if key.State == KeyRetired {
+			continue
+		}
+		switch publicKey := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.method.Alg(),
+				N:         base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.method.Alg(),
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		}
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID
+	})
+	return jwks
+}
+
+// sign signs claims with the active key.
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.active.method, claims)
+	if k.active.ID != "" {
+		token.Header["kid"] = k.active.ID
+	}
+	return token.SignedString(k.active.signingKey)
+}
+
+// keyFunc picks the key named by the token's kid header, rejecting retired
+// keys and tokens whose algorithm does not match their key.
+func (k *Keyring) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	if key.State == KeyRetired {
+		return nil, fmt.Errorf("key %q is retired", kid)
+	}
+	if t.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
+
+func newHMACKey(secret string, state KeyState) *Key {
+	return &Key{
+		State:      state,
+		method:     jwt.SigningMethodHS256,
+		signingKey: []byte(secret),
+		verifyKey:  []byte(secret),
+	}
+}
+
+func (key *Key) loadPrivateKey(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("no PEM data in %s", path)
+	}
+
+	var privateKey interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch privateKey := privateKey.(type) {
+	case *rsa.PrivateKey:
+		key.method = jwt.SigningMethodRS256
+		key.signingKey = privateKey
+		key.verifyKey = &privateKey.PublicKey
+	case ed25519.PrivateKey:
+		key.method = jwt.SigningMethodEdDSA
+		key.signingKey = privateKey
+		key.verifyKey = privateKey.Public()
+	default:
+		return fmt.Errorf("unsupported key type %T in %s", privateKey, path)
+	}
+	return nil
+}
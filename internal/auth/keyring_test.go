@@ -0,0 +1,388 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// testKey is an entry of a keyring directory written by writeKeyring. Keys
+// with no private key have no file.
+type testKey struct {
+	id      string
+	state   KeyState
+	key     interface{}
+	pemType string
+}
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func generateEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// writeKeyring writes keys and their manifest to a new directory and returns
+// it.
+func writeKeyring(t *testing.T, keys ...testKey) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	manifest := keyringManifest{}
+	for _, key := range keys {
+		file := key.id + ".pem"
+		manifest.Keys = append(manifest.Keys, struct {
+			ID    string   `json:"kid"`
+			File  string   `json:"file"`
+			State KeyState `json:"state"`
+		}{ID: key.id, File: file, State: key.state})
+		if key.key == nil {
+			continue
+		}
+
+		var der []byte
+		var err error
+		if key.pemType == "RSA PRIVATE KEY" {
+			der = x509.MarshalPKCS1PrivateKey(key.key.(*rsa.PrivateKey))
+		} else {
+			der, err = x509.MarshalPKCS8PrivateKey(key.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		pemType := key.pemType
+		if pemType == "" {
+			pemType = "PRIVATE KEY"
+		}
+		data := pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: der})
+		if err := os.WriteFile(filepath.Join(dir, file), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, keyringManifestFile), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func loadTestKeyring(t *testing.T, legacySecret string, keys ...testKey) *Keyring {
+	t.Helper()
+
+	keyring, err := LoadKeyring(writeKeyring(t, keys...), legacySecret)
+	if err != nil {
+		t.Fatalf("LoadKeyring: %v", err)
+	}
+	return keyring
+}
+
+func makeTestJWT(t *testing.T, keyring *Keyring) string {
+	t.Helper()
+
+	token, err := MakeJWT(uuid.New(), uuid.Nil, keyring, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeJWT: %v", err)
+	}
+	return token
+}
+
+// tokenHeader returns the decoded header of a signed token.
+func tokenHeader(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+
+	encoded, _, _ := strings.Cut(token, ".")
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("decoding token header: %v", err)
+	}
+	header := map[string]interface{}{}
+	if err := json.Unmarshal(data, &header); err != nil {
+		t.Fatalf("decoding token header: %v", err)
+	}
+	return header
+}
+
+func TestLoadKeyring(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     interface{}
+		pemType string
+		alg     string
+	}{
+		{name: "RSA PKCS#8", key: generateRSAKey(t), alg: "RS256"},
+		{name: "RSA PKCS#1", key: generateRSAKey(t), pemType: "RSA PRIVATE KEY", alg: "RS256"},
+		{name: "Ed25519", key: generateEd25519Key(t), alg: "EdDSA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring := loadTestKeyring(t, "", testKey{id: "current", state: KeyActive, key: tt.key, pemType: tt.pemType})
+
+			token := makeTestJWT(t, keyring)
+			header := tokenHeader(t, token)
+			if header["alg"] != tt.alg || header["kid"] != "current" {
+				t.Errorf("header = %v, want alg %s and kid current", header, tt.alg)
+			}
+			if _, err := ValidateJWT(token, keyring); err != nil {
+				t.Errorf("ValidateJWT: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadKeyringErrors(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey := generateEd25519Key(t)
+	active := testKey{id: "current", state: KeyActive, key: edKey}
+
+	tests := []struct {
+		name string
+		keys []testKey
+	}{
+		{name: "no keys"},
+		{name: "no active key", keys: []testKey{{id: "next", state: KeyInactive, key: edKey}}},
+		{name: "two active keys", keys: []testKey{active, {id: "other", state: KeyActive, key: edKey}}},
+		{name: "no kid", keys: []testKey{active, {state: KeyInactive, key: edKey}}},
+		{name: "duplicate kid", keys: []testKey{active, {id: "current", state: KeyInactive, key: edKey}}},
+		{name: "unknown state", keys: []testKey{active, {id: "next", state: "pending", key: edKey}}},
+		{name: "missing file", keys: []testKey{active, {id: "next", state: KeyInactive}}},
+		{name: "unsupported key type", keys: []testKey{{id: "current", state: KeyActive, key: ecKey}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadKeyring(writeKeyring(t, tt.keys...), ""); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	t.Run("no manifest", func(t *testing.T) {
+		if _, err := LoadKeyring(t.TempDir(), ""); err == nil {
+			t.Error("expected an error")
+		}
+	})
+	t.Run("invalid manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, keyringManifestFile), []byte(`{"keys": {}}`), 0o600)
+		if _, err := LoadKeyring(dir, ""); err == nil {
+			t.Error("expected an error")
+		}
+	})
+	t.Run("not PEM", func(t *testing.T) {
+		dir := writeKeyring(t, active)
+		os.WriteFile(filepath.Join(dir, "current.pem"), []byte("not a key"), 0o600)
+		if _, err := LoadKeyring(dir, ""); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldKey, newKey := generateEd25519Key(t), generateEd25519Key(t)
+	before := loadTestKeyring(t, "", testKey{id: "old", state: KeyActive, key: oldKey})
+	oldToken := makeTestJWT(t, before)
+
+	// The new key signs while tokens of the old one are still accepted.
+	during := loadTestKeyring(t, "",
+		testKey{id: "old", state: KeyInactive, key: oldKey},
+		testKey{id: "new", state: KeyActive, key: newKey},
+	)
+	if _, err := ValidateJWT(oldToken, during); err != nil {
+		t.Errorf("token of an inactive key: %v", err)
+	}
+	if kid := tokenHeader(t, makeTestJWT(t, during))["kid"]; kid != "new" {
+		t.Errorf("signed with %v, want the active key", kid)
+	}
+
+	// Retired keys are rejected, even though their file is gone.
+	after := loadTestKeyring(t, "",
+		testKey{id: "old", state: KeyRetired},
+		testKey{id: "new", state: KeyActive, key: newKey},
+	)
+	if _, err := ValidateJWT(oldToken, after); err == nil {
+		t.Error("token of a retired key was accepted")
+	}
+
+	// So are keys the keyring never had.
+	other := loadTestKeyring(t, "", testKey{id: "unknown", state: KeyActive, key: oldKey})
+	if _, err := ValidateJWT(makeTestJWT(t, other), after); err == nil {
+		t.Error("token of an unknown key was accepted")
+	}
+}
+
+func TestKeyringLegacySecret(t *testing.T) {
+	key := testKey{id: "current", state: KeyActive, key: generateEd25519Key(t)}
+	legacyToken := makeTestJWT(t, NewHMACKeyring("legacy secret"))
+
+	if _, err := ValidateJWT(legacyToken, loadTestKeyring(t, "legacy secret", key)); err != nil {
+		t.Errorf("token signed with the legacy secret: %v", err)
+	}
+	if _, err := ValidateJWT(legacyToken, loadTestKeyring(t, "", key)); err == nil {
+		t.Error("token signed with a legacy secret was accepted without one")
+	}
+	if _, err := ValidateJWT(legacyToken, loadTestKeyring(t, "another secret", key)); err == nil {
+		t.Error("token signed with another secret was accepted")
+	}
+}
+
+// TestKeyringRejectsAlgorithmConfusion checks that HS256 tokens are not
+// verified with the public half of an asymmetric key used as an HMAC secret.
+func TestKeyringRejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey, edKey := generateRSAKey(t), generateEd25519Key(t)
+	keyring := loadTestKeyring(t, "legacy secret",
+		testKey{id: "rsa", state: KeyActive, key: rsaKey},
+		testKey{id: "ed25519", state: KeyInactive, key: edKey},
+	)
+
+	publicPEM := func(key interface{}) []byte {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	}
+	tests := []struct {
+		kid    string
+		secret []byte
+	}{
+		{kid: "rsa", secret: publicPEM(&rsaKey.PublicKey)},
+		{kid: "rsa", secret: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)},
+		{kid: "ed25519", secret: publicPEM(edKey.Public())},
+		{kid: "ed25519", secret: edKey.Public().(ed25519.PublicKey)},
+		// The legacy secret is only valid for tokens without a kid.
+		{kid: "rsa", secret: []byte("legacy secret")},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%s %d", tt.kid, i), func(t *testing.T) {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+				Subject:   uuid.NewString(),
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			})
+			token.Header["kid"] = tt.kid
+			signed, err := token.SignedString(tt.secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ValidateJWT(signed, keyring); err == nil {
+				t.Error("HS256 token was accepted")
+			}
+		})
+	}
+
+	// The library refuses mismatched key types on its own; the keyring does
+	// not rely on it and checks the algorithm of the key before handing it out.
+	for _, tt := range []struct {
+		kid    string
+		method jwt.SigningMethod
+	}{
+		{kid: "rsa", method: jwt.SigningMethodHS256},
+		{kid: "rsa", method: jwt.SigningMethodEdDSA},
+		{kid: "ed25519", method: jwt.SigningMethodHS256},
+		{kid: "ed25519", method: jwt.SigningMethodRS256},
+		{kid: "", method: jwt.SigningMethodRS256},
+	} {
+		token := jwt.New(tt.method)
+		token.Header["kid"] = tt.kid
+		if key, err := keyring.keyFunc(token); err == nil {
+			t.Errorf("keyFunc returned %T for a %s token of key %q", key, tt.method.Alg(), tt.kid)
+		}
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, edKey := generateRSAKey(t), generateEd25519Key(t)
+	keyring := loadTestKeyring(t, "legacy secret",
+		testKey{id: "b-rsa", state: KeyActive, key: rsaKey},
+		testKey{id: "a-ed25519", state: KeyInactive, key: edKey},
+		testKey{id: "c-retired", state: KeyRetired},
+	)
+
+	data, err := json.Marshal(keyring.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := struct {
+		Keys []map[string]string `json:"keys"`
+	}{}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		t.Fatal(err)
+	}
+
+	// Retired keys and the legacy secret are not published, and keys are
+	// listed by kid.
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS = %s, want 2 keys", data)
+	}
+	encode := base64.RawURLEncoding.EncodeToString
+	want := []map[string]string{
+		{
+			"kty": "OKP",
+			"kid": "a-ed25519",
+			"use": "sig",
+			"alg": "EdDSA",
+			"crv": "Ed25519",
+			"x":   encode(edKey.Public().(ed25519.PublicKey)),
+		},
+		{
+			"kty": "RSA",
+			"kid": "b-rsa",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   encode(rsaKey.N.Bytes()),
+			"e":   encode(big.NewInt(int64(rsaKey.E)).Bytes()),
+		},
+	}
+	for i, key := range jwks.Keys {
+		if len(key) != len(want[i]) {
+			t.Errorf("key %d = %v, want %v", i, key, want[i])
+			continue
+		}
+		for name, value := range want[i] {
+			if key[name] != value {
+				t.Errorf("key %d: %s = %q, want %q", i, name, key[name], value)
+			}
+		}
+	}
+
+	if got := NewHMACKeyring("secret").JWKS(); len(got.Keys) != 0 {
+		t.Errorf("JWKS of a shared secret = %+v, want no keys", got)
+	}
+	if data, _ := json.Marshal(NewHMACKeyring("secret").JWKS()); string(data) != `{"keys":[]}` {
+		t.Errorf("empty JWKS = %s", data)
+	}
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 4226 and RFC 6238 test vectors.
+const rfcSecret = "12345678901234567890"
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226, appendix D.
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+	for counter, code := range want {
+		if got := hotp([]byte(rfcSecret), int64(counter)); got != code {
+			t.Errorf("hotp(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte(rfcSecret))
+
+	// RFC 6238, appendix B, SHA-1. The RFC gives 8 digits; the 6 digit code is
+	// their last 6.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		code := tt.code[len(tt.code)-totpDigits:]
+		step, ok := ValidateTOTP(secret, code, time.Unix(tt.unix, 0))
+		if !ok {
+			t.Errorf("ValidateTOTP(%s) at %d failed", code, tt.unix)
+			continue
+		}
+		if want := tt.unix / totpPeriod; step != want {
+			t.Errorf("ValidateTOTP(%s) at %d = step %d, want %d", code, tt.unix, step, want)
+		}
+	}
+
+	at := time.Unix(1111111111, 0)
+	if _, ok := ValidateTOTP(strings.ToLower(secret), "050471", at); !ok {
+		t.Error("lowercase secret was refused")
+	}
+	if _, ok := ValidateTOTP(secret, " 050471\n", at); !ok {
+		t.Error("code with surrounding spaces was refused")
+	}
+	for _, code := range []string{"", "05047", "0504710", "14050471", "050472"} {
+		if _, ok := ValidateTOTP(secret, code, at); ok {
+			t.Errorf("ValidateTOTP accepted %q", code)
+		}
+	}
+	if _, ok := ValidateTOTP("not base32!", "050471", at); ok {
+		t.Error("ValidateTOTP accepted an invalid secret")
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte(rfcSecret))
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / totpPeriod
+
+	// One period of drift either way is allowed, and the step the code
+	// belongs to is reported.
+	for offset := int64(-totpSkewSteps - 1); offset <= totpSkewSteps+1; offset++ {
+		code := hotp([]byte(rfcSecret), step+offset)
+		got, ok := ValidateTOTP(secret, code, now)
+		if inWindow := offset >= -totpSkewSteps && offset <= totpSkewSteps; ok != inWindow {
+			t.Errorf("code of step %+d: ok = %v, want %v", offset, ok, inWindow)
+			continue
+		}
+		if ok && got != step+offset {
+			t.Errorf("code of step %+d: step = %d, want %d", offset, got, step+offset)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Package broker fans out events published in this process to any number of
+// subscribers, keeping a short history so reconnecting clients can resume.
+package broker
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Event is a single published message. IDs increase monotonically for the
+// lifetime of the Broker. ChirpIDs lists the chirps the event is about, so
+// subscribers can follow individual chirps, and UserIDs the authors of its
+// content, so subscribers can leave out the users they do not want to see.
+type Event struct {
+	ID       uint64
+	Type     string
+	AuthorID uuid.UUID
+	ChirpIDs []uuid.UUID
+	UserIDs  []uuid.UUID
+	Data     json.RawMessage
+}
+
+// Filter decides whether a subscriber receives an event. A nil Filter
+// accepts every event.
+type Filter func(Event) bool
+
+// Subscription delivers events to one subscriber. C is closed when the
+// subscription ends, either through Unsubscribe or because the subscriber
+// fell too far behind.
+type Subscription struct {
+	C <-chan Event
+
+	events chan Event
+	filter Filter
+	broker *Broker
+}
+
+type Broker struct {
+	mu          sync.Mutex
+	nextID      uint64
+	history     []Event
+	historyHead int
+	historyLen  int
+	bufferSize  int
+	subscribers map[*Subscription]struct{}
+}
+
+// New returns a Broker remembering the last historySize events and giving each
+// subscriber a buffer of bufferSize events.
+func New(historySize, bufferSize int) *Broker {
+	return &Broker{
+		nextID:      1,
+		history:     make([]Event, historySize),
+		bufferSize:  bufferSize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns event an ID, sets its data to data encoded as JSON and
+// delivers it to every matching subscriber. It never blocks: a subscriber
+// whose buffer is full is dropped and has to reconnect, resuming from the
+// last event it received.
+func (b *Broker) Publish(event Event, data any) (Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	event.ID = b.nextID
+	event.Data = payload
+	b.nextID++
+	b.remember(event)
+
+	for sub := range b.subscribers {
+		if !sub.accepts(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			b.drop(sub)
+		}
+	}
+	return event, nil
+}
+
+// Subscribe registers a new subscriber. Events published after lastEventID
+// that are still in the history are returned so the caller can send them
+// before reading from the subscription; pass 0 to skip the replay.
+func (b *Broker) Subscribe(lastEventID uint64, filter Filter) (*Subscription, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make(chan Event, b.bufferSize)
+	sub := &Subscription{
+		C:      events,
+		events: events,
+		filter: filter,
+		broker: b,
+	}
+	b.subscribers[sub] = struct{}{}
+
+	if lastEventID == 0 || lastEventID >= b.nextID {
+		return sub, nil
+	}
+
+	missed := []Event{}
+	for i := 0; i < b.historyLen; i++ {
+		event := b.history[(b.historyHead+i)%len(b.history)]
+		if event.ID > lastEventID && sub.accepts(event) {
+			missed = append(missed, event)
+		}
+	}
+	return sub, missed
+}
+
+// Unsubscribe ends the subscription. It is safe to call more than once.
+func (s *Subscription) Unsubscribe() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.drop(s)
+}
+
+func (s *Subscription) accepts(event Event) bool {
+	return s.filter == nil || s.filter(event)
+}
+
+// remember appends event to the ring buffer, overwriting the oldest entry
+// once it is full. Callers must hold b.mu.
+func (b *Broker) remember(event Event) {
+	if len(b.history) == 0 {
+		return
+	}
+	if b.historyLen < len(b.history) {
+		b.history[(b.historyHead+b.historyLen)%len(b.history)] = event
+		b.historyLen++
+		return
+	}
+	b.history[b.historyHead] = event
+	b.historyHead = (b.historyHead + 1) % len(b.history)
+}
+
+// drop removes sub and closes its channel. Callers must hold b.mu.
+func (b *Broker) drop(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.events)
+}
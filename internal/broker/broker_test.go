@@ -0,0 +1,171 @@
+package broker
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func publish(t *testing.T, b *Broker, eventType string) Event {
+	t.Helper()
+
+	event, err := b.Publish(Event{Type: eventType}, map[string]string{"type": eventType})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	return event
+}
+
+func eventIDs(events []Event) []uint64 {
+	ids := make([]uint64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func TestPublishAssignsIDsAndData(t *testing.T) {
+	b := New(4, 4)
+
+	first := publish(t, b, "a")
+	second := publish(t, b, "b")
+	if first.ID != 1 || second.ID != 2 {
+		t.Errorf("IDs = %d, %d, want 1, 2", first.ID, second.ID)
+	}
+	if string(first.Data) != `{"type":"a"}` {
+		t.Errorf("Data = %s", first.Data)
+	}
+
+	if _, err := b.Publish(Event{Type: "c"}, make(chan int)); err == nil {
+		t.Error("expected an error for data that cannot be encoded")
+	}
+	if third := publish(t, b, "c"); third.ID != 3 {
+		t.Errorf("a failed publish used an ID: next ID = %d, want 3", third.ID)
+	}
+}
+
+func TestHistoryWrapsAround(t *testing.T) {
+	b := New(3, 1)
+	for i := 0; i < 7; i++ {
+		publish(t, b, "chirp_created")
+	}
+
+	// Only the last three events are left: 5, 6 and 7, oldest first.
+	sub, missed := b.Subscribe(1, nil)
+	defer sub.Unsubscribe()
+	if got, want := eventIDs(missed), []uint64{5, 6, 7}; !slices.Equal(got, want) {
+		t.Errorf("replayed %v, want %v", got, want)
+	}
+}
+
+func TestSubscribeReplaysAfterLastEventID(t *testing.T) {
+	b := New(10, 10)
+	for i := 0; i < 5; i++ {
+		publish(t, b, "chirp_created")
+	}
+
+	tests := []struct {
+		name        string
+		lastEventID uint64
+		want        []uint64
+	}{
+		{name: "no replay", lastEventID: 0, want: nil},
+		{name: "missed some", lastEventID: 3, want: []uint64{4, 5}},
+		{name: "up to date", lastEventID: 5, want: nil},
+		{name: "from the future", lastEventID: 99, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, missed := b.Subscribe(tt.lastEventID, nil)
+			defer sub.Unsubscribe()
+			if got := eventIDs(missed); !slices.Equal(got, tt.want) {
+				t.Errorf("replayed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	b := New(10, 10)
+	chirpID := uuid.New()
+	onlyChirp := func(event Event) bool {
+		return slices.Contains(event.ChirpIDs, chirpID)
+	}
+
+	b.Publish(Event{Type: "chirp_created", ChirpIDs: []uuid.UUID{uuid.New()}}, nil)
+	b.Publish(Event{Type: "chirp_liked", ChirpIDs: []uuid.UUID{chirpID}}, nil)
+
+	sub, missed := b.Subscribe(1, onlyChirp)
+	defer sub.Unsubscribe()
+	if got := eventIDs(missed); !slices.Equal(got, []uint64{2}) {
+		t.Errorf("replayed %v, want [2]", got)
+	}
+
+	b.Publish(Event{Type: "chirp_created", ChirpIDs: []uuid.UUID{uuid.New()}}, nil)
+	live, _ := b.Publish(Event{Type: "chirp_deleted", ChirpIDs: []uuid.UUID{chirpID}}, nil)
+	if event := <-sub.C; event.ID != live.ID {
+		t.Errorf("received event %d, want %d", event.ID, live.ID)
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	b := New(10, 2)
+	slow, _ := b.Subscribe(0, nil)
+	fast, _ := b.Subscribe(0, nil)
+	defer fast.Unsubscribe()
+
+	received := []uint64{}
+	for i := 0; i < 3; i++ {
+		publish(t, b, "chirp_created")
+		received = append(received, (<-fast.C).ID)
+	}
+
+	// The slow subscriber got the two events its buffer holds, then its
+	// channel was closed instead of blocking Publish.
+	got := []uint64{}
+	for event := range slow.C {
+		got = append(got, event.ID)
+	}
+	if !slices.Equal(got, []uint64{1, 2}) {
+		t.Errorf("slow subscriber received %v, want [1 2]", got)
+	}
+	if !slices.Equal(received, []uint64{1, 2, 3}) {
+		t.Errorf("fast subscriber received %v, want [1 2 3]", received)
+	}
+
+	// It can resume from the last event it saw.
+	resumed, missed := b.Subscribe(2, nil)
+	defer resumed.Unsubscribe()
+	if ids := eventIDs(missed); !slices.Equal(ids, []uint64{3}) {
+		t.Errorf("replayed %v, want [3]", ids)
+	}
+
+	// Unsubscribing a dropped subscription is harmless.
+	slow.Unsubscribe()
+}
+
+func TestUnsubscribe(t *testing.T) {
+	b := New(10, 10)
+	sub, _ := b.Subscribe(0, nil)
+
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	if _, ok := <-sub.C; ok {
+		t.Error("channel still open after Unsubscribe")
+	}
+
+	// Publishing to no subscribers still works.
+	publish(t, b, "chirp_created")
+}
+
+func TestNoHistory(t *testing.T) {
+	b := New(0, 1)
+	publish(t, b, "chirp_created")
+
+	sub, missed := b.Subscribe(1, nil)
+	defer sub.Unsubscribe()
+	if len(missed) != 0 {
+		t.Errorf("replayed %v without a history", eventIDs(missed))
+	}
+}
@@ -13,17 +13,20 @@ import (
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, created_at, updated_at, body, user_id
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, reply_to_id, repost_of_id, quote_of_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, created_at, updated_at, body, user_id, reply_to_id, repost_of_id, quote_of_id, search_vector
 `
 
 type CreateChirpParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Body      string
-	UserID    uuid.UUID
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Body       string
+	UserID     uuid.UUID
+	ReplyToID  uuid.NullUUID
+	RepostOfID uuid.NullUUID
+	QuoteOfID  uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
@@ -33,6 +36,9 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		arg.UpdatedAt,
 		arg.Body,
 		arg.UserID,
+		arg.ReplyToID,
+		arg.RepostOfID,
+		arg.QuoteOfID,
 	)
 	var i Chirp
 	err := row.Scan(
@@ -41,17 +47,76 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ReplyToID,
+		&i.RepostOfID,
+		&i.QuoteOfID,
+		&i.SearchVector,
 	)
 	return i, err
 }
 
-const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at
+const deleteChirp = `-- name: DeleteChirp :exec
+DELETE FROM chirps
+WHERE id = $1
+`
+
+func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteChirp, id)
+	return err
+}
+
+const deleteRepost = `-- name: DeleteRepost :one
+WITH deleted AS (
+    DELETE FROM chirps
+    WHERE chirps.user_id = $1
+        AND chirps.repost_of_id = (
+            SELECT COALESCE(target.repost_of_id, target.id) FROM chirps AS target
+            WHERE target.id = $2
+        )
+    RETURNING chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.reply_to_id, chirps.repost_of_id, chirps.quote_of_id, chirps.search_vector
+)
+SELECT deleted.id, deleted.created_at, deleted.updated_at, deleted.user_id, deleted.repost_of_id,
+    original.user_id AS original_user_id
+FROM deleted
+JOIN chirps AS original ON original.id = deleted.repost_of_id
+`
+
+type DeleteRepostParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+type DeleteRepostRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	UserID         uuid.UUID
+	RepostOfID     uuid.NullUUID
+	OriginalUserID uuid.UUID
+}
+
+func (q *Queries) DeleteRepost(ctx context.Context, arg DeleteRepostParams) (DeleteRepostRow, error) {
+	row := q.db.QueryRowContext(ctx, deleteRepost, arg.UserID, arg.ChirpID)
+	var i DeleteRepostRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.RepostOfID,
+		&i.OriginalUserID,
+	)
+	return i, err
+}
+
+const deleteRepostsOf = `-- name: DeleteRepostsOf :many
+DELETE FROM chirps
+WHERE repost_of_id = $1
+RETURNING id, created_at, updated_at, body, user_id, reply_to_id, repost_of_id, quote_of_id, search_vector
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+func (q *Queries) DeleteRepostsOf(ctx context.Context, repostOfID uuid.NullUUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, deleteRepostsOf, repostOfID)
 	if err != nil {
 		return nil, err
 	}
@@ -65,6 +130,303 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllChirps = `-- name: GetAllChirps :many
+SELECT chirp_listings.id, chirp_listings.created_at, chirp_listings.updated_at, chirp_listings.body, chirp_listings.user_id, chirp_listings.reply_to_id, chirp_listings.repost_of_id, chirp_listings.quote_of_id, chirp_listings.like_count, chirp_listings.original_id, chirp_listings.original_created_at, chirp_listings.original_updated_at, chirp_listings.original_body, chirp_listings.original_user_id, chirp_listings.original_like_count,
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = $1::uuid) AS liked_by_me
+FROM chirp_listings
+WHERE ($2::uuid IS NULL OR chirp_listings.user_id = $2::uuid)
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = $1::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+    AND (chirp_listings.created_at, chirp_listings.id) > ($3::timestamp, $4::uuid)
+ORDER BY chirp_listings.created_at, chirp_listings.id
+LIMIT $5
+`
+
+type GetAllChirpsParams struct {
+	ViewerID       uuid.NullUUID
+	AuthorID       uuid.NullUUID
+	AfterCreatedAt time.Time
+	AfterID        uuid.UUID
+	PageSize       int32
+}
+
+type GetAllChirpsRow struct {
+	ChirpListing ChirpListing
+	LikedByMe    bool
+}
+
+func (q *Queries) GetAllChirps(ctx context.Context, arg GetAllChirpsParams) ([]GetAllChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirps,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAllChirpsRow
+	for rows.Next() {
+		var i GetAllChirpsRow
+		if err := rows.Scan(
+			&i.ChirpListing.ID,
+			&i.ChirpListing.CreatedAt,
+			&i.ChirpListing.UpdatedAt,
+			&i.ChirpListing.Body,
+			&i.ChirpListing.UserID,
+			&i.ChirpListing.ReplyToID,
+			&i.ChirpListing.RepostOfID,
+			&i.ChirpListing.QuoteOfID,
+			&i.ChirpListing.LikeCount,
+			&i.ChirpListing.OriginalID,
+			&i.ChirpListing.OriginalCreatedAt,
+			&i.ChirpListing.OriginalUpdatedAt,
+			&i.ChirpListing.OriginalBody,
+			&i.ChirpListing.OriginalUserID,
+			&i.ChirpListing.OriginalLikeCount,
+			&i.LikedByMe,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllChirpsDesc = `-- name: GetAllChirpsDesc :many
+SELECT chirp_listings.id, chirp_listings.created_at, chirp_listings.updated_at, chirp_listings.body, chirp_listings.user_id, chirp_listings.reply_to_id, chirp_listings.repost_of_id, chirp_listings.quote_of_id, chirp_listings.like_count, chirp_listings.original_id, chirp_listings.original_created_at, chirp_listings.original_updated_at, chirp_listings.original_body, chirp_listings.original_user_id, chirp_listings.original_like_count,
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = $1::uuid) AS liked_by_me
+FROM chirp_listings
+WHERE ($2::uuid IS NULL OR chirp_listings.user_id = $2::uuid)
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = $1::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+    AND (chirp_listings.created_at, chirp_listings.id) < ($3::timestamp, $4::uuid)
+ORDER BY chirp_listings.created_at DESC, chirp_listings.id DESC
+LIMIT $5
+`
+
+type GetAllChirpsDescParams struct {
+	ViewerID        uuid.NullUUID
+	AuthorID        uuid.NullUUID
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	PageSize        int32
+}
+
+type GetAllChirpsDescRow struct {
+	ChirpListing ChirpListing
+	LikedByMe    bool
+}
+
+func (q *Queries) GetAllChirpsDesc(ctx context.Context, arg GetAllChirpsDescParams) ([]GetAllChirpsDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirpsDesc,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAllChirpsDescRow
+	for rows.Next() {
+		var i GetAllChirpsDescRow
+		if err := rows.Scan(
+			&i.ChirpListing.ID,
+			&i.ChirpListing.CreatedAt,
+			&i.ChirpListing.UpdatedAt,
+			&i.ChirpListing.Body,
+			&i.ChirpListing.UserID,
+			&i.ChirpListing.ReplyToID,
+			&i.ChirpListing.RepostOfID,
+			&i.ChirpListing.QuoteOfID,
+			&i.ChirpListing.LikeCount,
+			&i.ChirpListing.OriginalID,
+			&i.ChirpListing.OriginalCreatedAt,
+			&i.ChirpListing.OriginalUpdatedAt,
+			&i.ChirpListing.OriginalBody,
+			&i.ChirpListing.OriginalUserID,
+			&i.ChirpListing.OriginalLikeCount,
+			&i.LikedByMe,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors (id, depth) AS (
+    SELECT c.reply_to_id, 1 FROM chirps AS c
+    WHERE c.id = $2 AND c.reply_to_id IS NOT NULL
+    UNION ALL
+    SELECT c.reply_to_id, ancestors.depth + 1 FROM chirps AS c
+    JOIN ancestors ON c.id = ancestors.id
+    WHERE c.reply_to_id IS NOT NULL
+)
+SELECT chirp_listings.id, chirp_listings.created_at, chirp_listings.updated_at, chirp_listings.body, chirp_listings.user_id, chirp_listings.reply_to_id, chirp_listings.repost_of_id, chirp_listings.quote_of_id, chirp_listings.like_count, chirp_listings.original_id, chirp_listings.original_created_at, chirp_listings.original_updated_at, chirp_listings.original_body, chirp_listings.original_user_id, chirp_listings.original_like_count,
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = $1::uuid) AS liked_by_me
+FROM chirp_listings
+JOIN ancestors ON chirp_listings.id = ancestors.id
+WHERE NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = $1::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+ORDER BY ancestors.depth DESC
+`
+
+type GetChirpAncestorsParams struct {
+	ViewerID uuid.NullUUID
+	ID       uuid.UUID
+}
+
+type GetChirpAncestorsRow struct {
+	ChirpListing ChirpListing
+	LikedByMe    bool
+}
+
+func (q *Queries) GetChirpAncestors(ctx context.Context, arg GetChirpAncestorsParams) ([]GetChirpAncestorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, arg.ViewerID, arg.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpAncestorsRow
+	for rows.Next() {
+		var i GetChirpAncestorsRow
+		if err := rows.Scan(
+			&i.ChirpListing.ID,
+			&i.ChirpListing.CreatedAt,
+			&i.ChirpListing.UpdatedAt,
+			&i.ChirpListing.Body,
+			&i.ChirpListing.UserID,
+			&i.ChirpListing.ReplyToID,
+			&i.ChirpListing.RepostOfID,
+			&i.ChirpListing.QuoteOfID,
+			&i.ChirpListing.LikeCount,
+			&i.ChirpListing.OriginalID,
+			&i.ChirpListing.OriginalCreatedAt,
+			&i.ChirpListing.OriginalUpdatedAt,
+			&i.ChirpListing.OriginalBody,
+			&i.ChirpListing.OriginalUserID,
+			&i.ChirpListing.OriginalLikeCount,
+			&i.LikedByMe,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpReplies = `-- name: GetChirpReplies :many
+SELECT chirp_listings.id, chirp_listings.created_at, chirp_listings.updated_at, chirp_listings.body, chirp_listings.user_id, chirp_listings.reply_to_id, chirp_listings.repost_of_id, chirp_listings.quote_of_id, chirp_listings.like_count, chirp_listings.original_id, chirp_listings.original_created_at, chirp_listings.original_updated_at, chirp_listings.original_body, chirp_listings.original_user_id, chirp_listings.original_like_count,
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = $1::uuid) AS liked_by_me
+FROM chirp_listings
+WHERE chirp_listings.reply_to_id = $2
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = $1::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+    AND (chirp_listings.created_at, chirp_listings.id) > ($3::timestamp, $4::uuid)
+ORDER BY chirp_listings.created_at, chirp_listings.id
+LIMIT $5
+`
+
+type GetChirpRepliesParams struct {
+	ViewerID       uuid.NullUUID
+	ChirpID        uuid.NullUUID
+	AfterCreatedAt time.Time
+	AfterID        uuid.UUID
+	PageSize       int32
+}
+
+type GetChirpRepliesRow struct {
+	ChirpListing ChirpListing
+	LikedByMe    bool
+}
+
+func (q *Queries) GetChirpReplies(ctx context.Context, arg GetChirpRepliesParams) ([]GetChirpRepliesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpReplies,
+		arg.ViewerID,
+		arg.ChirpID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpRepliesRow
+	for rows.Next() {
+		var i GetChirpRepliesRow
+		if err := rows.Scan(
+			&i.ChirpListing.ID,
+			&i.ChirpListing.CreatedAt,
+			&i.ChirpListing.UpdatedAt,
+			&i.ChirpListing.Body,
+			&i.ChirpListing.UserID,
+			&i.ChirpListing.ReplyToID,
+			&i.ChirpListing.RepostOfID,
+			&i.ChirpListing.QuoteOfID,
+			&i.ChirpListing.LikeCount,
+			&i.ChirpListing.OriginalID,
+			&i.ChirpListing.OriginalCreatedAt,
+			&i.ChirpListing.OriginalUpdatedAt,
+			&i.ChirpListing.OriginalBody,
+			&i.ChirpListing.OriginalUserID,
+			&i.ChirpListing.OriginalLikeCount,
+			&i.LikedByMe,
 		); err != nil {
 			return nil, err
 		}
@@ -80,23 +442,66 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getOneChirp = `-- name: GetOneChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE id = $1
+SELECT chirp_listings.id, chirp_listings.created_at, chirp_listings.updated_at, chirp_listings.body, chirp_listings.user_id, chirp_listings.reply_to_id, chirp_listings.repost_of_id, chirp_listings.quote_of_id, chirp_listings.like_count, chirp_listings.original_id, chirp_listings.original_created_at, chirp_listings.original_updated_at, chirp_listings.original_body, chirp_listings.original_user_id, chirp_listings.original_like_count,
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = $1::uuid) AS liked_by_me
+FROM chirp_listings
+WHERE chirp_listings.id = $2
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = $1::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+            AND NOT hidden_users.muted
+    )
 `
 
-func (q *Queries) GetOneChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getOneChirp, id)
-	var i Chirp
+type GetOneChirpParams struct {
+	ViewerID uuid.NullUUID
+	ID       uuid.UUID
+}
+
+type GetOneChirpRow struct {
+	ChirpListing ChirpListing
+	LikedByMe    bool
+}
+
+func (q *Queries) GetOneChirp(ctx context.Context, arg GetOneChirpParams) (GetOneChirpRow, error) {
+	row := q.db.QueryRowContext(ctx, getOneChirp, arg.ViewerID, arg.ID)
+	var i GetOneChirpRow
 	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Body,
-		&i.UserID,
+		&i.ChirpListing.ID,
+		&i.ChirpListing.CreatedAt,
+		&i.ChirpListing.UpdatedAt,
+		&i.ChirpListing.Body,
+		&i.ChirpListing.UserID,
+		&i.ChirpListing.ReplyToID,
+		&i.ChirpListing.RepostOfID,
+		&i.ChirpListing.QuoteOfID,
+		&i.ChirpListing.LikeCount,
+		&i.ChirpListing.OriginalID,
+		&i.ChirpListing.OriginalCreatedAt,
+		&i.ChirpListing.OriginalUpdatedAt,
+		&i.ChirpListing.OriginalBody,
+		&i.ChirpListing.OriginalUserID,
+		&i.ChirpListing.OriginalLikeCount,
+		&i.LikedByMe,
 	)
 	return i, err
 }
 
+const incrementChirpReadCount = `-- name: IncrementChirpReadCount :one
+INSERT INTO chirp_read_counts (chirp_id, read_count)
+VALUES ($1, 1)
+ON CONFLICT (chirp_id) DO UPDATE SET read_count = chirp_read_counts.read_count + 1
+RETURNING read_count
+`
+
+func (q *Queries) IncrementChirpReadCount(ctx context.Context, chirpID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, incrementChirpReadCount, chirpID)
+	var read_count int64
+	err := row.Scan(&read_count)
+	return read_count, err
+}
+
 const resetChirpsTable = `-- name: ResetChirpsTable :exec
 DELETE FROM chirps
 `
@@ -105,3 +510,82 @@ func (q *Queries) ResetChirpsTable(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetChirpsTable)
 	return err
 }
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT chirp_listings.id, chirp_listings.created_at, chirp_listings.updated_at, chirp_listings.body, chirp_listings.user_id, chirp_listings.reply_to_id, chirp_listings.repost_of_id, chirp_listings.quote_of_id, chirp_listings.like_count, chirp_listings.original_id, chirp_listings.original_created_at, chirp_listings.original_updated_at, chirp_listings.original_body, chirp_listings.original_user_id, chirp_listings.original_like_count,
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = $1::uuid) AS liked_by_me,
+    ts_rank(chirps.search_vector, query) AS rank,
+    ts_headline('english', replace(replace(replace(chirp_listings.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), query, 'StartSel=<mark>, StopSel=</mark>')::text AS snippet
+FROM chirp_listings
+JOIN chirps ON chirps.id = chirp_listings.id
+CROSS JOIN websearch_to_tsquery('english', $2::text) AS query
+WHERE chirps.search_vector @@ query
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = $1::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+ORDER BY rank DESC, chirp_listings.created_at DESC, chirp_listings.id DESC
+LIMIT $4 OFFSET $3
+`
+
+type SearchChirpsParams struct {
+	ViewerID   uuid.NullUUID
+	Query      string
+	PageOffset int32
+	PageSize   int32
+}
+
+type SearchChirpsRow struct {
+	ChirpListing ChirpListing
+	LikedByMe    bool
+	Rank         float32
+	Snippet      string
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.ViewerID,
+		arg.Query,
+		arg.PageOffset,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.ChirpListing.ID,
+			&i.ChirpListing.CreatedAt,
+			&i.ChirpListing.UpdatedAt,
+			&i.ChirpListing.Body,
+			&i.ChirpListing.UserID,
+			&i.ChirpListing.ReplyToID,
+			&i.ChirpListing.RepostOfID,
+			&i.ChirpListing.QuoteOfID,
+			&i.ChirpListing.LikeCount,
+			&i.ChirpListing.OriginalID,
+			&i.ChirpListing.OriginalCreatedAt,
+			&i.ChirpListing.OriginalUpdatedAt,
+			&i.ChirpListing.OriginalBody,
+			&i.ChirpListing.OriginalUserID,
+			&i.ChirpListing.OriginalLikeCount,
+			&i.LikedByMe,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
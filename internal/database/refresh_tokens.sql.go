@@ -14,7 +14,7 @@ import (
 )
 
 const getRefreshTokenByToken = `-- name: GetRefreshTokenByToken :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at FROM refresh_tokens
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, parent_token, user_agent, ip_address, last_used_at, rotated_at FROM refresh_tokens
 WHERE token = $1
 `
 
@@ -28,23 +28,90 @@ func (q *Queries) GetRefreshTokenByToken(ctx context.Context, token string) (Ref
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.ParentToken,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+		&i.RotatedAt,
 	)
 	return i, err
 }
 
+const getSessionsForUser = `-- name: GetSessionsForUser :many
+SELECT
+    t.family_id,
+    (SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = t.family_id)::timestamp AS started_at,
+    t.user_agent,
+    t.ip_address,
+    t.last_used_at,
+    t.expires_at
+FROM refresh_tokens t
+WHERE t.user_id = $1 AND t.revoked_at IS NULL AND t.expires_at > $2::timestamp
+ORDER BY t.last_used_at DESC
+`
+
+type GetSessionsForUserParams struct {
+	UserID uuid.UUID
+	Now    time.Time
+}
+
+type GetSessionsForUserRow struct {
+	FamilyID   uuid.UUID
+	StartedAt  time.Time
+	UserAgent  string
+	IpAddress  string
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
+
+func (q *Queries) GetSessionsForUser(ctx context.Context, arg GetSessionsForUserParams) ([]GetSessionsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSessionsForUser, arg.UserID, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSessionsForUserRow
+	for rows.Next() {
+		var i GetSessionsForUserRow
+		if err := rows.Scan(
+			&i.FamilyID,
+			&i.StartedAt,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastUsedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const insertRefreshTokenIntoDB = `-- name: InsertRefreshTokenIntoDB :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, parent_token, user_agent, ip_address, last_used_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $2)
+RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, parent_token, user_agent, ip_address, last_used_at, rotated_at
 `
 
 type InsertRefreshTokenIntoDBParams struct {
-	Token     string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	ExpiresAt time.Time
-	RevokedAt sql.NullTime
+	Token       string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	ExpiresAt   time.Time
+	RevokedAt   sql.NullTime
+	FamilyID    uuid.UUID
+	ParentToken sql.NullString
+	UserAgent   string
+	IpAddress   string
 }
 
 func (q *Queries) InsertRefreshTokenIntoDB(ctx context.Context, arg InsertRefreshTokenIntoDBParams) (RefreshToken, error) {
@@ -55,6 +122,10 @@ func (q *Queries) InsertRefreshTokenIntoDB(ctx context.Context, arg InsertRefres
 		arg.UserID,
 		arg.ExpiresAt,
 		arg.RevokedAt,
+		arg.FamilyID,
+		arg.ParentToken,
+		arg.UserAgent,
+		arg.IpAddress,
 	)
 	var i RefreshToken
 	err := row.Scan(
@@ -64,15 +135,38 @@ func (q *Queries) InsertRefreshTokenIntoDB(ctx context.Context, arg InsertRefres
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.ParentToken,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+		&i.RotatedAt,
 	)
 	return i, err
 }
 
+const revokeAllRefreshTokensForUser = `-- name: RevokeAllRefreshTokensForUser :exec
+UPDATE refresh_tokens
+SET revoked_at = $2, updated_at = $3
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+type RevokeAllRefreshTokensForUserParams struct {
+	UserID    uuid.UUID
+	RevokedAt sql.NullTime
+	UpdatedAt time.Time
+}
+
+func (q *Queries) RevokeAllRefreshTokensForUser(ctx context.Context, arg RevokeAllRefreshTokensForUserParams) error {
+	_, err := q.db.ExecContext(ctx, revokeAllRefreshTokensForUser, arg.UserID, arg.RevokedAt, arg.UpdatedAt)
+	return err
+}
+
 const revokeRefreshToken = `-- name: RevokeRefreshToken :one
 UPDATE refresh_tokens
 SET revoked_at = $2, updated_at = $3
-WHERE token = $1
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at
+WHERE token = $1 AND revoked_at IS NULL
+RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, parent_token, user_agent, ip_address, last_used_at, rotated_at
 `
 
 type RevokeRefreshTokenParams struct {
@@ -91,6 +185,74 @@ func (q *Queries) RevokeRefreshToken(ctx context.Context, arg RevokeRefreshToken
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.ParentToken,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+		&i.RotatedAt,
 	)
 	return i, err
 }
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = $3, updated_at = $4
+WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeRefreshTokenFamilyParams struct {
+	UserID    uuid.UUID
+	FamilyID  uuid.UUID
+	RevokedAt sql.NullTime
+	UpdatedAt time.Time
+}
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, arg RevokeRefreshTokenFamilyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily,
+		arg.UserID,
+		arg.FamilyID,
+		arg.RevokedAt,
+		arg.UpdatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rotateRefreshToken = `-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = $1, rotated_at = $1, updated_at = $1
+WHERE token = $2 AND revoked_at IS NULL
+`
+
+type RotateRefreshTokenParams struct {
+	RotatedAt sql.NullTime
+	Token     string
+}
+
+func (q *Queries) RotateRefreshToken(ctx context.Context, arg RotateRefreshTokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rotateRefreshToken, arg.RotatedAt, arg.Token)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const touchSession = `-- name: TouchSession :exec
+UPDATE refresh_tokens
+SET last_used_at = $1::timestamp
+WHERE family_id = $2 AND revoked_at IS NULL AND last_used_at < $3::timestamp
+`
+
+type TouchSessionParams struct {
+	Now        time.Time
+	FamilyID   uuid.UUID
+	UsedBefore time.Time
+}
+
+func (q *Queries) TouchSession(ctx context.Context, arg TouchSessionParams) error {
+	_, err := q.db.ExecContext(ctx, touchSession, arg.Now, arg.FamilyID, arg.UsedBefore)
+	return err
+}
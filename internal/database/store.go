@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Store adds transaction support on top of the generated Queries.
+type Store struct {
+	*Queries
+	conn *sql.DB
+}
+
+func NewStore(conn *sql.DB) *Store {
+	return &Store{
+		Queries: New(conn),
+		conn:    conn,
+	}
+}
+
+// ExecTx runs fn inside a single transaction. The transaction is committed if
+// fn returns nil and rolled back otherwise.
+func (s *Store) ExecTx(ctx context.Context, fn func(q *Queries) error) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s.WithTx(tx)); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,227 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession is what the stand-in server received during one session.
+type smtpSession struct {
+	commands []string
+	data     string
+}
+
+// serveSMTP accepts a single connection on a local port and speaks just enough
+// SMTP for SMTPMailer. It does not offer STARTTLS or AUTH.
+func serveSMTP(t *testing.T) (string, <-chan smtpSession) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		session := smtpSession{}
+		defer func() { sessions <- session }()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) {
+			conn.Write([]byte(line + "\r\n"))
+		}
+		reply("220 localhost ESMTP stand-in")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.TrimRight(line, "\r\n")
+			session.commands = append(session.commands, command)
+
+			switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+			case "EHLO":
+				reply("250-localhost")
+				reply("250 8BITMIME")
+			case "HELO", "MAIL", "RCPT", "RSET", "NOOP":
+				reply("250 OK")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				session.data = data.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), sessions
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	addr, sessions := serveSMTP(t)
+	m := NewSMTPMailer(addr, "Chirpy <no-reply@chirpy.test>", "", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := m.Send(ctx, Message{
+		To:      "walt@example.com",
+		Subject: "Réinitialiser",
+		Body:    "Hello\r\n.hidden\r\n",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var session smtpSession
+	select {
+	case session = <-sessions:
+	case <-ctx.Done():
+		t.Fatal("stand-in server did not finish the session")
+	}
+
+	for _, command := range session.commands {
+		if strings.HasPrefix(strings.ToUpper(command), "STARTTLS") || strings.HasPrefix(strings.ToUpper(command), "AUTH") {
+			t.Errorf("unexpected command %q when the server offers neither", command)
+		}
+	}
+	wantCommands := []string{
+		"MAIL FROM:<no-reply@chirpy.test>",
+		"RCPT TO:<walt@example.com>",
+		"DATA",
+		"QUIT",
+	}
+	for _, want := range wantCommands {
+		found := false
+		for _, command := range session.commands {
+			if strings.HasPrefix(command, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("command %q not sent, got %q", want, session.commands)
+		}
+	}
+
+	header, body, ok := strings.Cut(session.data, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("message has no header separator: %q", session.data)
+	}
+	wantHeaders := []string{
+		"From: Chirpy <no-reply@chirpy.test>",
+		"To: walt@example.com",
+		"Subject: =?utf-8?q?R=C3=A9initialiser?=",
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+	headerLines := strings.Split(header, "\r\n")
+	for _, want := range wantHeaders {
+		found := false
+		for _, line := range headerLines {
+			if line == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("header %q missing from %q", want, headerLines)
+		}
+	}
+	if !strings.HasPrefix(header, "Date: ") && !strings.Contains(header, "\r\nDate: ") {
+		t.Errorf("Date header missing from %q", headerLines)
+	}
+	// The leading dot is doubled on the wire.
+	if body != "Hello\r\n..hidden\r\n" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	accepted := make(chan struct{}, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn.Close()
+		accepted <- struct{}{}
+	}()
+
+	m := NewSMTPMailer(listener.Addr().String(), "no-reply@chirpy.test", "", "")
+	tests := []struct {
+		name    string
+		message Message
+	}{
+		{
+			name:    "subject",
+			message: Message{To: "walt@example.com", Subject: "Hi\r\nBcc: eve@example.com"},
+		},
+		{
+			name:    "recipient",
+			message: Message{To: "walt@example.com\nBcc: eve@example.com", Subject: "Hi"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.Send(context.Background(), tt.message); err == nil {
+				t.Fatal("expected an error for a line break in a header")
+			}
+		})
+	}
+
+	select {
+	case <-accepted:
+		t.Error("mailer connected to the server before rejecting the message")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	m := &MemoryMailer{}
+	message := Message{To: "walt@example.com", Subject: "Hi", Body: "Hello"}
+	if err := m.Send(context.Background(), message); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	messages := m.Messages()
+	if len(messages) != 1 || messages[0] != message {
+		t.Fatalf("Messages() = %v", messages)
+	}
+	messages[0].To = "changed"
+	if m.Messages()[0].To != "walt@example.com" {
+		t.Error("Messages() returned the internal slice")
+	}
+}
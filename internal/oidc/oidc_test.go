@@ -0,0 +1,298 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leonardomlouzas/GOose/internal/oidc/oidctest"
+)
+
+const testClientID = "chirpy"
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Server) {
+	t.Helper()
+
+	idp := oidctest.NewServer(testClientID)
+	t.Cleanup(idp.Close)
+	provider := NewProvider(Config{
+		Issuer:      idp.Issuer() + "/",
+		ClientID:    testClientID,
+		RedirectURL: "http://chirpy.test/api/oidc/callback",
+	}, idp.Client())
+	return provider, idp
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	provider, idp := newTestProvider(t)
+
+	rawURL, err := provider.AuthCodeURL(context.Background(), "the-state", "the-nonce", "the-verifier")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	authURL, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", rawURL, err)
+	}
+	if got := authURL.Scheme + "://" + authURL.Host + authURL.Path; got != idp.URL+"/authorize" {
+		t.Errorf("endpoint = %q, want the discovered one", got)
+	}
+
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          "http://chirpy.test/api/oidc/callback",
+		"scope":                 "openid email profile",
+		"state":                 "the-state",
+		"nonce":                 "the-nonce",
+		"code_challenge":        codeChallenge("the-verifier"),
+		"code_challenge_method": "S256",
+	}
+	query := authURL.Query()
+	for name, value := range want {
+		if got := query.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// Example from RFC 7636, appendix B.
+	got := codeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("codeChallenge = %q, want %q", got, want)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"issuer": "https://evil.example", "authorization_endpoint": "https://evil.example/authorize",
+			"token_endpoint": "https://evil.example/token", "jwks_uri": "https://evil.example/jwks"}`))
+	}))
+	defer server.Close()
+
+	provider := NewProvider(Config{Issuer: server.URL, ClientID: testClientID}, server.Client())
+	if _, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Fatal("expected an error for metadata of another issuer")
+	}
+}
+
+func TestExchangeAndVerify(t *testing.T) {
+	provider, idp := newTestProvider(t)
+	ctx := context.Background()
+
+	claims := idp.Claims("subject-1", "the-nonce", "walt@example.com")
+	claims["name"] = "Walter White"
+	code := idp.IssueCode(claims)
+
+	rawIDToken, err := provider.Exchange(ctx, code, "the-verifier")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	requests := idp.TokenRequests()
+	if len(requests) != 1 {
+		t.Fatalf("%d token requests, want 1", len(requests))
+	}
+	if got := requests[0].Get("code_verifier"); got != "the-verifier" {
+		t.Errorf("code_verifier = %q", got)
+	}
+	if got := requests[0].Get("redirect_uri"); got != "http://chirpy.test/api/oidc/callback" {
+		t.Errorf("redirect_uri = %q", got)
+	}
+
+	verified, err := provider.VerifyIDToken(ctx, rawIDToken, "the-nonce")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if verified.Subject != "subject-1" || verified.Email != "walt@example.com" || !verified.EmailVerified || verified.Name != "Walter White" {
+		t.Errorf("claims = %+v", verified)
+	}
+
+	if _, err := provider.Exchange(ctx, code, "the-verifier"); err == nil {
+		t.Error("expected an error when exchanging a code twice")
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	provider, idp := newTestProvider(t)
+	other := oidctest.NewServer(testClientID)
+	defer other.Close()
+
+	tests := []struct {
+		name   string
+		modify func(claims jwt.MapClaims)
+		sign   func(claims jwt.MapClaims) string
+	}{
+		{
+			name:   "wrong nonce",
+			modify: func(claims jwt.MapClaims) { claims["nonce"] = "another-nonce" },
+		},
+		{
+			name:   "wrong audience",
+			modify: func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+		},
+		{
+			name: "several audiences without azp",
+			modify: func(claims jwt.MapClaims) {
+				claims["aud"] = []string{testClientID, "another-client"}
+			},
+		},
+		{
+			name: "authorized for another party",
+			modify: func(claims jwt.MapClaims) {
+				claims["aud"] = []string{testClientID, "another-client"}
+				claims["azp"] = "another-client"
+			},
+		},
+		{
+			name:   "wrong issuer",
+			modify: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example" },
+		},
+		{
+			name:   "expired",
+			modify: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		},
+		{
+			name:   "no expiry",
+			modify: func(claims jwt.MapClaims) { delete(claims, "exp") },
+		},
+		{
+			name:   "no subject",
+			modify: func(claims jwt.MapClaims) { delete(claims, "sub") },
+		},
+		{
+			name: "signed by another provider",
+			sign: other.SignIDToken,
+		},
+		{
+			name: "unsigned",
+			sign: func(claims jwt.MapClaims) string {
+				token, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				return token
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := idp.Claims("subject-1", "the-nonce", "walt@example.com")
+			if tt.modify != nil {
+				tt.modify(claims)
+			}
+			sign := idp.SignIDToken
+			if tt.sign != nil {
+				sign = tt.sign
+			}
+			if _, err := provider.VerifyIDToken(context.Background(), sign(claims), "the-nonce"); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenSeveralAudiences(t *testing.T) {
+	provider, idp := newTestProvider(t)
+
+	claims := idp.Claims("subject-1", "the-nonce", "walt@example.com")
+	claims["aud"] = []string{testClientID, "another-client"}
+	claims["azp"] = testClientID
+	if _, err := provider.VerifyIDToken(context.Background(), idp.SignIDToken(claims), "the-nonce"); err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+}
+
+func TestVerifyIDTokenKeyRotation(t *testing.T) {
+	provider, idp := newTestProvider(t)
+	ctx := context.Background()
+	claims := idp.Claims("subject-1", "the-nonce", "walt@example.com")
+
+	if _, err := provider.VerifyIDToken(ctx, idp.SignIDToken(claims), "the-nonce"); err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+
+	idp.RotateKey()
+	rotated := idp.SignIDToken(claims)
+	// Unknown keys only trigger a new fetch once keysRefreshInterval passed.
+	if _, err := provider.VerifyIDToken(ctx, rotated, "the-nonce"); err == nil {
+		t.Fatal("expected the key set not to be fetched again right away")
+	}
+
+	provider.keysFetchedAt = time.Now().Add(-keysRefreshInterval)
+	if _, err := provider.VerifyIDToken(ctx, rotated, "the-nonce"); err != nil {
+		t.Fatalf("VerifyIDToken after rotation: %v", err)
+	}
+}
+
+func TestLookupKey(t *testing.T) {
+	provider := NewProvider(Config{}, nil)
+
+	provider.keys = map[string]interface{}{"a": "key a"}
+	if key, ok := provider.lookupKey(""); !ok || key != "key a" {
+		t.Errorf("a token without kid should use the only key, got %v, %v", key, ok)
+	}
+
+	provider.keys["b"] = "key b"
+	if _, ok := provider.lookupKey(""); ok {
+		t.Error("a token without kid is ambiguous with several keys")
+	}
+	if key, ok := provider.lookupKey("b"); !ok || key != "key b" {
+		t.Errorf("lookupKey(b) = %v, %v", key, ok)
+	}
+	if _, ok := provider.lookupKey("c"); ok {
+		t.Error("lookupKey found an unknown key")
+	}
+}
+
+func TestJSONWebKeyPublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := base64.RawURLEncoding.EncodeToString
+
+	rsaJWK := jsonWebKey{KeyType: "RSA", N: encode(rsaKey.N.Bytes()), E: encode(big.NewInt(int64(rsaKey.E)).Bytes())}
+	if key, err := rsaJWK.publicKey(); err != nil || !rsaKey.PublicKey.Equal(key) {
+		t.Errorf("RSA key = %v, %v", key, err)
+	}
+
+	ecJWK := jsonWebKey{KeyType: "EC", Curve: "P-256", X: encode(ecKey.X.Bytes()), Y: encode(ecKey.Y.Bytes())}
+	if key, err := ecJWK.publicKey(); err != nil || !ecKey.PublicKey.Equal(key) {
+		t.Errorf("EC key = %v, %v", key, err)
+	}
+
+	edJWK := jsonWebKey{KeyType: "OKP", Curve: "Ed25519", X: encode(edKey)}
+	if key, err := edJWK.publicKey(); err != nil || !edKey.Equal(key) {
+		t.Errorf("Ed25519 key = %v, %v", key, err)
+	}
+
+	for _, jwk := range []jsonWebKey{
+		{KeyType: "oct"},
+		{KeyType: "EC", Curve: "P-521"},
+		{KeyType: "OKP", Curve: "X25519"},
+		{KeyType: "OKP", Curve: "Ed25519", X: encode([]byte("short"))},
+		{KeyType: "RSA", N: "not base64!", E: "AQAB"},
+	} {
+		if _, err := jwk.publicKey(); err == nil {
+			t.Errorf("expected an error for %+v", jwk)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package oidctest runs a minimal OpenID Connect identity provider for tests.
+// It serves discovery, a key set and a token endpoint; authorization codes
+// are issued directly by the test instead of through a login page.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Server is an identity provider for the client ClientID.
+type Server struct {
+	*httptest.Server
+	ClientID string
+
+	mu            sync.Mutex
+	keyID         string
+	key           *rsa.PrivateKey
+	codes         map[string]string
+	tokenRequests []url.Values
+	nextID        int
+}
+
+// NewServer starts a provider with a single RSA signing key. Callers should
+// call Close when done.
+func NewServer(clientID string) *Server {
+	s := &Server{
+		ClientID: clientID,
+		codes:    make(map[string]string),
+	}
+	s.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("GET /jwks", s.handleJWKS)
+	mux.HandleFunc("POST /token", s.handleToken)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Issuer is the URL the provider identifies itself with.
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// Claims returns valid ID token claims for subject and nonce, with a verified
+// email. Tests change them to produce the token they need.
+func (s *Server) Claims(subject, nonce, email string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            s.Issuer(),
+		"aud":            s.ClientID,
+		"sub":            subject,
+		"nonce":          nonce,
+		"email":          email,
+		"email_verified": true,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+}
+
+// SignIDToken signs claims with the current key.
+func (s *Server) SignIDToken(claims jwt.MapClaims) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.keyID
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		panic("oidctest: signing ID token: " + err.Error())
+	}
+	return signed
+}
+
+// IssueCode returns an authorization code that the token endpoint exchanges,
+// once, for an ID token with claims.
+func (s *Server) IssueCode(claims jwt.MapClaims) string {
+	idToken := s.SignIDToken(claims)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	code := "code-" + strconv.Itoa(s.nextID)
+	s.codes[code] = idToken
+	return code
+}
+
+// RotateKey replaces the signing key with a new one under a new key ID.
+// Tokens signed before no longer verify against the key set.
+func (s *Server) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic("oidctest: generating key: " + err.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.keyID = "key-" + strconv.Itoa(s.nextID)
+	s.key = key
+}
+
+// TokenRequests returns the forms posted to the token endpoint so far.
+func (s *Server) TokenRequests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]url.Values(nil), s.tokenRequests...)
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 s.Issuer(),
+		"authorization_endpoint": s.URL + "/authorize",
+		"token_endpoint":         s.URL + "/token",
+		"jwks_uri":               s.URL + "/jwks",
+	})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": s.keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenRequests = append(s.tokenRequests, r.PostForm)
+
+	idToken, ok := s.codes[r.PostForm.Get("code")]
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("client_id") != s.ClientID {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	delete(s.codes, r.PostForm.Get("code"))
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+type Liker struct {
+	User
+	LikedAt	time.Time	`json:"liked_at"`
+}
+
+func (cfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	chirp, ok := cfg.requireTargetChirp(w, r, uuid.NullUUID{UUID: userID, Valid: true})
+	if !ok {
+		return
+	}
+
+	err = cfg.db.LikeChirp(r.Context(), database.LikeChirpParams{
+		UserID:    userID,
+		ChirpID:   chirp.ID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "chirp already liked")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error liking chirp")
+		log.Printf("error inserting like of chirp %s by user %s: %v", chirp.ID, userID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	chirp, ok := cfg.requireTargetChirp(w, r, uuid.NullUUID{UUID: userID, Valid: true})
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error unliking chirp")
+		log.Printf("error deleting like of chirp %s by user %s: %v", chirp.ID, userID, err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "chirp not liked")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetChirpLikes(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.optionalUserID(r, scopeChirpsRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	chirp, ok := cfg.requireTargetChirp(w, r, viewerID)
+	if !ok {
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := cfg.db.GetChirpLikes(r.Context(), database.GetChirpLikesParams{
+		ChirpID:       chirp.ID,
+		BeforeLikedAt: page.before().Time,
+		BeforeID:      page.before().ID,
+		PageSize:      page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving likes")
+		log.Printf("error retrieving likes of chirp %s: %v", chirp.ID, err)
+		return
+	}
+
+	likers := make([]Liker, len(rows))
+	for i, row := range rows {
+		likers[i] = Liker{
+			User:    databaseUserToUser(row.User),
+			LikedAt: row.LikedAt,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, paginate(w, r, page, likers, likerCursor))
+}
+
+func likerCursor(liker Liker) pageCursor {
+	return pageCursor{Time: liker.LikedAt, ID: liker.ID}
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+const maxListNameLength = 50
+
+type List struct {
+	ID			uuid.UUID	`json:"id"`
+	CreatedAt	time.Time	`json:"created_at"`
+	UpdatedAt	time.Time	`json:"updated_at"`
+	OwnerID		uuid.UUID	`json:"owner_id"`
+	Name		string		`json:"name"`
+	IsPrivate	bool		`json:"is_private"`
+}
+
+type ListMember struct {
+	User
+	AddedAt	time.Time	`json:"added_at"`
+}
+
+func (cfg *apiConfig) handlerCreateList(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name		string	`json:"name"`
+		IsPrivate	bool	`json:"is_private"`
+	}
+
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		log.Printf("error decoding request payload while creating list: %v", err)
+		return
+	}
+
+	name, err := validateListName(params.Name)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	list, err := cfg.db.CreateList(r.Context(), database.CreateListParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		OwnerID:   userID,
+		Name:      name,
+		IsPrivate: params.IsPrivate,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error creating list")
+		log.Printf("error inserting list into db for user %s: %v", userID, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, databaseListToList(list))
+}
+
+func (cfg *apiConfig) handlerGetMyLists(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	dbLists, err := cfg.db.GetListsByOwner(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving lists")
+		log.Printf("error retrieving lists owned by %s: %v", userID, err)
+		return
+	}
+
+	lists := make([]List, len(dbLists))
+	for i, dbList := range dbLists {
+		lists[i] = databaseListToList(dbList)
+	}
+	respondWithJSON(w, http.StatusOK, lists)
+}
+
+func (cfg *apiConfig) handlerGetList(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.optionalUserID(r, scopeUsersRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	list, ok := cfg.getVisibleList(w, r, viewerID)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseListToList(list))
+}
+
+func (cfg *apiConfig) handlerUpdateList(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name		string	`json:"name"`
+		IsPrivate	*bool	`json:"is_private"`
+	}
+
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	list, ok := cfg.getOwnedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		log.Printf("error decoding request payload while updating list: %v", err)
+		return
+	}
+
+	name := list.Name
+	if strings.TrimSpace(params.Name) != "" {
+		name, err = validateListName(params.Name)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	isPrivate := list.IsPrivate
+	if params.IsPrivate != nil {
+		isPrivate = *params.IsPrivate
+	}
+
+	updatedList, err := cfg.db.UpdateList(r.Context(), database.UpdateListParams{
+		ID:        list.ID,
+		Name:      name,
+		IsPrivate: isPrivate,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error updating list")
+		log.Printf("error updating list %s: %v", list.ID, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseListToList(updatedList))
+}
+
+func (cfg *apiConfig) handlerDeleteList(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	list, ok := cfg.getOwnedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	err = cfg.db.DeleteList(r.Context(), list.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error deleting list")
+		log.Printf("error deleting list %s: %v", list.ID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetListMembers(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.optionalUserID(r, scopeUsersRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	list, ok := cfg.getVisibleList(w, r, viewerID)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetListMembers(r.Context(), list.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving list members")
+		log.Printf("error retrieving members of list %s: %v", list.ID, err)
+		return
+	}
+
+	members := make([]ListMember, len(rows))
+	for i, row := range rows {
+		members[i] = ListMember{
+			User:    databaseUserToUser(row.User),
+			AddedAt: row.AddedAt,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, members)
+}
+
+func (cfg *apiConfig) handlerAddListMember(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		UserID	uuid.UUID	`json:"user_id"`
+	}
+
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	list, ok := cfg.getOwnedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil || params.UserID == uuid.Nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		log.Printf("error decoding request payload while adding list member: %v", err)
+		return
+	}
+
+	_, err = cfg.db.GetUserById(r.Context(), params.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		log.Printf("error retrieving user by id: %s. Error: %v", params.UserID, err)
+		return
+	}
+
+	err = cfg.db.AddListMember(r.Context(), database.AddListMemberParams{
+		ListID:    list.ID,
+		UserID:    params.UserID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "user is already a member of this list")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error adding list member")
+		log.Printf("error adding user %s to list %s: %v", params.UserID, list.ID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRemoveListMember(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	list, ok := cfg.getOwnedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	deleted, err := cfg.db.RemoveListMember(r.Context(), database.RemoveListMemberParams{
+		ListID: list.ID,
+		UserID: memberID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error removing list member")
+		log.Printf("error removing user %s from list %s: %v", memberID, list.ID, err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "user is not a member of this list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetListChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.optionalUserID(r, scopeChirpsRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	list, ok := cfg.getVisibleList(w, r, viewerID)
+	if !ok {
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := cfg.db.GetListChirps(r.Context(), database.GetListChirpsParams{
+		ViewerID:        viewerID,
+		ListID:          list.ID,
+		BeforeCreatedAt: page.before().Time,
+		BeforeID:        page.before().ID,
+		PageSize:        page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving list chirps")
+		log.Printf("error retrieving chirps of list %s: %v", list.ID, err)
+		return
+	}
+
+	chirps := make([]Chirp, len(rows))
+	for i, row := range rows {
+		chirps[i] = databaseChirpRowToChirp(chirpRow(row), viewerID)
+	}
+	respondWithJSON(w, http.StatusOK, paginate(w, r, page, chirps, chirpCursor))
+}
+
+// getVisibleList loads the {id} list if the viewer may see it. Private lists
+// are reported as missing to everyone but their owner.
+func (cfg *apiConfig) getVisibleList(w http.ResponseWriter, r *http.Request, viewerID uuid.NullUUID) (database.List, bool) {
+	listID := r.PathValue("id")
+	uid, err := uuid.Parse(listID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid list ID")
+		return database.List{}, false
+	}
+
+	list, err := cfg.db.GetList(r.Context(), uid)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "list not found")
+			return database.List{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving list")
+		log.Printf("error retrieving list by id %s: %v", listID, err)
+		return database.List{}, false
+	}
+
+	if list.IsPrivate && (!viewerID.Valid || viewerID.UUID != list.OwnerID) {
+		respondWithError(w, http.StatusNotFound, "list not found")
+		return database.List{}, false
+	}
+
+	return list, true
+}
+
+// getOwnedList loads the {id} list and makes sure userID owns it.
+func (cfg *apiConfig) getOwnedList(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.List, bool) {
+	list, ok := cfg.getVisibleList(w, r, uuid.NullUUID{UUID: userID, Valid: true})
+	if !ok {
+		return database.List{}, false
+	}
+
+	if list.OwnerID != userID {
+		respondWithError(w, http.StatusForbidden, "you can only modify your own lists")
+		return database.List{}, false
+	}
+
+	return list, true
+}
+
+func databaseListToList(list database.List) List {
+	return List{
+		ID:        list.ID,
+		CreatedAt: list.CreatedAt,
+		UpdatedAt: list.UpdatedAt,
+		OwnerID:   list.OwnerID,
+		Name:      list.Name,
+		IsPrivate: list.IsPrivate,
+	}
+}
+
+func validateListName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("list name cannot be empty")
+	}
+	if len(name) > maxListNameLength {
+		return "", fmt.Errorf("list name is too long")
+	}
+	return name, nil
+}
@@ -1,29 +1,43 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync/atomic"
 
 	"github.com/joho/godotenv"
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/auth"
+	"github.com/leonardomlouzas/GOose/internal/broker"
 	"github.com/leonardomlouzas/GOose/internal/database"
-	_ "github.com/lib/pq"
+	"github.com/leonardomlouzas/GOose/internal/mailer"
+	"github.com/leonardomlouzas/GOose/internal/oidc"
+	"github.com/lib/pq"
 )
 
 const filepathRoot = "."
 const port = "8080"
 
 type apiConfig struct {
-	db				*database.Queries
-	fileserverHits	atomic.Int32
-	env				string
-	jwt_secret		string
-	bannedWords		map[string]struct{}
+	db					*database.Store
+	fileserverHits		atomic.Int32
+	env					string
+	keyring				*auth.Keyring
+	bannedWords			map[string]struct{}
+	chirpReadThreshold	int64
+	events				*broker.Broker
+	messageMaxLength	int
+	mailer				mailer.Mailer
+	publicURL			string
+	oidcProvider		*oidc.Provider
 }
 
 func (cfg *apiConfig) handlerMetrics(w http.ResponseWriter, r *http.Request) {
@@ -65,6 +79,7 @@ func main() {
 	dbURL := os.Getenv("DB_URL")
 	env := os.Getenv("ENVIRONMENT")
 	jwt_secret := os.Getenv("JWT_SECRET")
+	jwtKeysDir := os.Getenv("JWT_KEYS_DIR")
 	bannedWordsRaw := os.Getenv("BANNED_WORDS")
 	bannedWordsList := strings.Split(bannedWordsRaw, " ")
 	bannedWordsMap := make(map[string]struct{})
@@ -72,6 +87,60 @@ func main() {
 		bannedWordsMap[strings.ToLower(word)] = struct{}{}
 	}
 
+	chirpReadThreshold := int64(defaultChirpReadThreshold)
+	if rawThreshold := os.Getenv("CHIRP_READ_NOTIFICATION_THRESHOLD"); rawThreshold != "" {
+		parsedThreshold, err := strconv.ParseInt(rawThreshold, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid CHIRP_READ_NOTIFICATION_THRESHOLD: %v\n", err)
+		}
+		chirpReadThreshold = parsedThreshold
+	}
+
+	messageMaxLength := defaultMessageMaxLength
+	if rawMaxLength := os.Getenv("MESSAGE_MAX_LENGTH"); rawMaxLength != "" {
+		parsedMaxLength, err := strconv.Atoi(rawMaxLength)
+		if err != nil || parsedMaxLength < 1 {
+			log.Fatalf("Invalid MESSAGE_MAX_LENGTH: %q\n", rawMaxLength)
+		}
+		messageMaxLength = parsedMaxLength
+	}
+
+	// Without a key directory tokens keep being signed with JWT_SECRET.
+	keyring := auth.NewHMACKeyring(jwt_secret)
+	if jwtKeysDir != "" {
+		loadedKeyring, err := auth.LoadKeyring(jwtKeysDir, jwt_secret)
+		if err != nil {
+			log.Fatalf("Could not load JWT keys from %s: %v\n", jwtKeysDir, err)
+		}
+		keyring = loadedKeyring
+	}
+
+	var appMailer mailer.Mailer
+	switch mailerKind := os.Getenv("MAILER"); mailerKind {
+	case "", "log":
+		appMailer = mailer.LogMailer{}
+	case "smtp":
+		appMailer = mailer.NewSMTPMailer(os.Getenv("SMTP_ADDR"), os.Getenv("MAIL_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+	default:
+		log.Fatalf("Invalid MAILER: %q\n", mailerKind)
+	}
+
+	publicURL := strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/")
+	if publicURL == "" {
+		publicURL = "http://localhost:" + port
+	}
+
+	// Single sign-on is only offered when an identity provider is configured.
+	var oidcProvider *oidc.Provider
+	if oidcIssuer := os.Getenv("OIDC_ISSUER"); oidcIssuer != "" {
+		oidcProvider = oidc.NewProvider(oidc.Config{
+			Issuer:       oidcIssuer,
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  publicURL + "/api/oidc/callback",
+		}, nil)
+	}
+
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		log.Fatalf("Could not connect to database: %v\n", err)
@@ -79,28 +148,91 @@ func main() {
 	defer db.Close()
 
 	apiCfg := &apiConfig{
-		db: database.New(db),
-		fileserverHits: atomic.Int32{},
-		env:            env,
-		jwt_secret:     jwt_secret,
-		bannedWords:    bannedWordsMap,
+		db: database.NewStore(db),
+		fileserverHits:     atomic.Int32{},
+		env:                env,
+		keyring:            keyring,
+		bannedWords:        bannedWordsMap,
+		chirpReadThreshold: chirpReadThreshold,
+		events:             broker.New(streamHistorySize, streamBufferSize),
+		messageMaxLength:   messageMaxLength,
+		mailer:             appMailer,
+		publicURL:          publicURL,
+		oidcProvider:       oidcProvider,
 	}
 
 	mux := http.NewServeMux()
 
 	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app/", http.FileServer(http.Dir(filepathRoot)))))
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
+	mux.HandleFunc("GET /.well-known/jwks.json", apiCfg.handlerJWKS)
 	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
 	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
 	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
+	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateUser)
 	mux.HandleFunc("GET /api/users", apiCfg.handlerGetAllUsers)
 	mux.HandleFunc("GET /api/users/{id}", apiCfg.handlerGetUserByID)
+	mux.HandleFunc("POST /api/users/{id}/follow", apiCfg.handlerFollowUser)
+	mux.HandleFunc("DELETE /api/users/{id}/follow", apiCfg.handlerUnfollowUser)
+	mux.HandleFunc("POST /api/users/{id}/block", apiCfg.handlerBlockUser)
+	mux.HandleFunc("DELETE /api/users/{id}/block", apiCfg.handlerUnblockUser)
+	mux.HandleFunc("POST /api/users/{id}/mute", apiCfg.handlerMuteUser)
+	mux.HandleFunc("DELETE /api/users/{id}/mute", apiCfg.handlerUnmuteUser)
+	mux.HandleFunc("GET /api/users/{id}/followers", apiCfg.handlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{id}/following", apiCfg.handlerGetFollowing)
+	mux.HandleFunc("GET /api/handles/{handle}", apiCfg.handlerGetUserByHandle)
 	mux.HandleFunc("POST /api/login", apiCfg.handlerLoginByPassword)
+	mux.HandleFunc("POST /api/login/2fa", apiCfg.handlerLoginSecondFactor)
+	mux.HandleFunc("GET /api/oidc/login", apiCfg.handlerOIDCLogin)
+	mux.HandleFunc("GET /api/oidc/callback", apiCfg.handlerOIDCCallback)
+	mux.HandleFunc("POST /api/2fa/enroll", apiCfg.handlerEnrollTOTP)
+	mux.HandleFunc("POST /api/2fa/confirm", apiCfg.handlerConfirmTOTP)
+	mux.HandleFunc("POST /api/2fa/disable", apiCfg.handlerDisableTOTP)
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefreshToken)
 	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevokeRefreshToken)
+	mux.HandleFunc("POST /api/password-reset/request", apiCfg.handlerRequestPasswordReset)
+	mux.HandleFunc("POST /api/password-reset/confirm", apiCfg.handlerConfirmPasswordReset)
+	mux.HandleFunc("GET /api/verify-email", apiCfg.handlerVerifyEmail)
+	mux.HandleFunc("POST /api/verify-email/resend", apiCfg.handlerResendVerificationEmail)
+	mux.HandleFunc("GET /api/sessions", apiCfg.handlerGetSessions)
+	mux.HandleFunc("DELETE /api/sessions/{id}", apiCfg.handlerRevokeSession)
+	mux.HandleFunc("POST /api/sessions/revoke-all", apiCfg.handlerRevokeAllSessions)
+	mux.HandleFunc("POST /api/tokens", apiCfg.handlerCreateAPIKey)
+	mux.HandleFunc("GET /api/tokens", apiCfg.handlerGetAPIKeys)
+	mux.HandleFunc("GET /api/tokens/{id}", apiCfg.handlerGetAPIKey)
+	mux.HandleFunc("PUT /api/tokens/{id}", apiCfg.handlerUpdateAPIKey)
+	mux.HandleFunc("DELETE /api/tokens/{id}", apiCfg.handlerRevokeAPIKey)
 	mux.HandleFunc("POST /api/chirps", apiCfg.handlerPostChirp)
 	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetAllChirps)
+	mux.HandleFunc("GET /api/stream", apiCfg.handlerStream)
+	mux.HandleFunc("GET /api/ws", apiCfg.handlerWebSocket)
+	mux.HandleFunc("GET /api/chirps/search", apiCfg.handlerSearchChirps)
 	mux.HandleFunc("GET /api/chirps/{id}", apiCfg.handlerGetOneChirp)
+	mux.HandleFunc("DELETE /api/chirps/{id}", apiCfg.handlerDeleteChirp)
+	mux.HandleFunc("GET /api/chirps/{id}/thread", apiCfg.handlerGetChirpThread)
+	mux.HandleFunc("POST /api/chirps/{id}/like", apiCfg.handlerLikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{id}/like", apiCfg.handlerUnlikeChirp)
+	mux.HandleFunc("GET /api/chirps/{id}/likes", apiCfg.handlerGetChirpLikes)
+	mux.HandleFunc("POST /api/chirps/{id}/repost", apiCfg.handlerRepostChirp)
+	mux.HandleFunc("DELETE /api/chirps/{id}/repost", apiCfg.handlerUndoRepost)
+	mux.HandleFunc("GET /api/hashtags/trending", apiCfg.handlerGetTrendingHashtags)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", apiCfg.handlerGetHashtagChirps)
+	mux.HandleFunc("GET /api/mentions", apiCfg.handlerGetMentions)
+	mux.HandleFunc("POST /api/conversations", apiCfg.handlerCreateConversation)
+	mux.HandleFunc("GET /api/conversations", apiCfg.handlerGetConversations)
+	mux.HandleFunc("GET /api/conversations/{id}/messages", apiCfg.handlerGetMessages)
+	mux.HandleFunc("POST /api/conversations/{id}/messages", apiCfg.handlerPostMessage)
+	mux.HandleFunc("GET /api/notifications", apiCfg.handlerGetNotifications)
+	mux.HandleFunc("POST /api/notifications/read", apiCfg.handlerMarkNotificationsRead)
+	mux.HandleFunc("POST /api/lists", apiCfg.handlerCreateList)
+	mux.HandleFunc("GET /api/lists", apiCfg.handlerGetMyLists)
+	mux.HandleFunc("GET /api/lists/{id}", apiCfg.handlerGetList)
+	mux.HandleFunc("PUT /api/lists/{id}", apiCfg.handlerUpdateList)
+	mux.HandleFunc("DELETE /api/lists/{id}", apiCfg.handlerDeleteList)
+	mux.HandleFunc("GET /api/lists/{id}/members", apiCfg.handlerGetListMembers)
+	mux.HandleFunc("POST /api/lists/{id}/members", apiCfg.handlerAddListMember)
+	mux.HandleFunc("DELETE /api/lists/{id}/members/{user_id}", apiCfg.handlerRemoveListMember)
+	mux.HandleFunc("GET /api/lists/{id}/chirps", apiCfg.handlerGetListChirps)
 
 	server := &http.Server {
 		Addr:		":" + port,
@@ -119,6 +251,13 @@ func handlerReadiness(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(http.StatusText(http.StatusOK)))
 }
 
+// handlerJWKS publishes the public keys access tokens can be validated with,
+// so other services can verify them without the signing keys.
+func (cfg *apiConfig) handlerJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, cfg.keyring.JWKS())
+}
+
 func respondWithError(w http.ResponseWriter, code int, msg string) {
 	type errResponse struct {
 		Error string `json:"error"`
@@ -139,3 +278,75 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.WriteHeader(code)
 	w.Write(data)
 }
+
+// authenticatedUserID returns the ID of the user owning the bearer JWT sent
+// with the request. It only accepts access tokens: endpoints that manage the
+// account itself, such as credentials and sessions, use it so that an API key
+// cannot be used to take the account over.
+func (cfg *apiConfig) authenticatedUserID(r *http.Request) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return cfg.validateAccessToken(r.Context(), token)
+}
+
+// authenticatedUserIDWithScope is like authenticatedUserID but also accepts
+// an API key that was granted scope.
+func (cfg *apiConfig) authenticatedUserIDWithScope(r *http.Request, scope string) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if auth.IsAPIKey(token) {
+		return cfg.validateAPIKey(r.Context(), token, scope)
+	}
+	return cfg.validateAccessToken(r.Context(), token)
+}
+
+// validateAccessToken returns the user owning the access token and records the
+// use of the session it was issued to.
+func (cfg *apiConfig) validateAccessToken(ctx context.Context, token string) (uuid.UUID, error) {
+	userID, sessionID, err := auth.ValidateJWTWithSession(token, cfg.keyring)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if sessionID != uuid.Nil {
+		cfg.touchSession(ctx, sessionID)
+	}
+	return userID, nil
+}
+
+// optionalUserID is like authenticatedUserIDWithScope for endpoints that also
+// serve anonymous callers. It only fails when a token is sent but is not valid.
+func (cfg *apiConfig) optionalUserID(r *http.Request, scope string) (uuid.NullUUID, error) {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, nil
+	}
+	userID, err := cfg.authenticatedUserIDWithScope(r, scope)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}, nil
+}
+
+// respondWithAuthError answers a request whose credentials were refused. A
+// valid API key without the needed scope is forbidden rather than
+// unauthorized, and credentials that could not be checked are a server error.
+func respondWithAuthError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errMissingScope) {
+		respondWithError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, errCheckingAPIKey) {
+		respondWithError(w, http.StatusInternalServerError, "error checking credentials")
+		return
+	}
+	respondWithError(w, http.StatusUnauthorized, "invalid token")
+}
+
+// isUniqueViolation reports whether err was caused by a unique constraint in postgres.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/auth"
+	"github.com/leonardomlouzas/GOose/internal/database"
+	"github.com/leonardomlouzas/GOose/internal/mailer"
+	_ "github.com/lib/pq"
+)
+
+// newTestConfig returns a config backed by a fresh schema of the database at
+// TEST_DATABASE_URL, with every migration applied. Tests that need it are
+// skipped when the variable is not set.
+func newTestConfig(t *testing.T) (*apiConfig, *mailer.MemoryMailer) {
+	t.Helper()
+
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	admin, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("creating schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Errorf("dropping schema %s: %v", schema, err)
+		}
+	})
+
+	schemaURL, err := url.Parse(dbURL)
+	if err != nil {
+		t.Fatalf("parsing TEST_DATABASE_URL: %v", err)
+	}
+	query := schemaURL.Query()
+	query.Set("search_path", schema)
+	schemaURL.RawQuery = query.Encode()
+
+	db, err := sql.Open("postgres", schemaURL.String())
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	applyMigrations(t, db)
+
+	memoryMailer := &mailer.MemoryMailer{}
+	return &apiConfig{
+		db:                 database.NewStore(db),
+		keyring:            auth.NewHMACKeyring("test-secret"),
+		bannedWords:        map[string]struct{}{},
+		chirpReadThreshold: defaultChirpReadThreshold,
+		messageMaxLength:   defaultMessageMaxLength,
+		mailer:             memoryMailer,
+		publicURL:          "http://chirpy.test",
+	}, memoryMailer
+}
+
+// applyMigrations runs the Up section of every file in sql/schema, in order.
+func applyMigrations(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join("sql", "schema", "*.sql"))
+	if err != nil {
+		t.Fatalf("listing migrations: %v", err)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		migration, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading migration: %v", err)
+		}
+		up, _, _ := strings.Cut(string(migration), "-- +goose Down")
+		if _, err := db.Exec(strings.TrimPrefix(up, "-- +goose Up")); err != nil {
+			t.Fatalf("applying %s: %v", path, err)
+		}
+	}
+}
+
+// createTestUser stores a user with the given email and password.
+func createTestUser(t *testing.T, cfg *apiConfig, email, password string) database.User {
+	t.Helper()
+
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		t.Fatalf("hashing password: %v", err)
+	}
+	id := uuid.New()
+	user, err := cfg.db.CreateUser(context.Background(), database.CreateUserParams{
+		ID:             id,
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+		Email:          email,
+		HashedPassword: hashedPassword,
+		Handle:         "user_" + id.String()[:8],
+	})
+	if err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	return user
+}
+
+// accessToken returns a bearer header value for userID.
+func accessToken(t *testing.T, cfg *apiConfig, userID uuid.UUID) string {
+	t.Helper()
+
+	token, err := auth.MakeJWT(userID, uuid.Nil, cfg.keyring, time.Hour)
+	if err != nil {
+		t.Fatalf("making access token: %v", err)
+	}
+	return "Bearer " + token
+}
+
+// waitForEmail returns the n-th message sent through m. Messages are sent in
+// the background, so it polls for a while.
+func waitForEmail(t *testing.T, m *mailer.MemoryMailer, n int) mailer.Message {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if messages := m.Messages(); len(messages) >= n {
+			return messages[n-1]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("email %d was not sent", n)
+	return mailer.Message{}
+}
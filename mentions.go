@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_])@([\p{L}\p{N}_]+)`)
+
+func (cfg *apiConfig) handlerGetMentions(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+	rows, err := cfg.db.GetMentionChirps(r.Context(), database.GetMentionChirpsParams{
+		ViewerID:        viewerID,
+		UserID:          userID,
+		BeforeCreatedAt: page.before().Time,
+		BeforeID:        page.before().ID,
+		PageSize:        page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving mentions")
+		log.Printf("error retrieving mentions of user %s: %v", userID, err)
+		return
+	}
+
+	chirps := make([]Chirp, len(rows))
+	for i, row := range rows {
+		chirps[i] = databaseChirpRowToChirp(chirpRow(row), viewerID)
+	}
+	respondWithJSON(w, http.StatusOK, paginate(w, r, page, chirps, chirpCursor))
+}
+
+// saveChirpMentions resolves the @handles in a freshly created chirp to users
+// and records a mention for each of them. Unknown handles are ignored. It runs
+// in the transaction that creates the chirp.
+func saveChirpMentions(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	handles := extractMentions(chirp.Body)
+	if len(handles) == 0 {
+		return nil
+	}
+
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		err = q.AddMention(ctx, database.AddMentionParams{
+			ChirpID:   chirp.ID,
+			UserID:    user.ID,
+			CreatedAt: chirp.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractMentions returns the distinct, lowercased handles mentioned in body.
+func extractMentions(body string) []string {
+	seen := make(map[string]struct{})
+	handles := []string{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		if !handlePattern.MatchString(match[1]) {
+			continue
+		}
+		handle := strings.ToLower(match[1])
+		if _, ok := seen[handle]; ok {
+			continue
+		}
+		seen[handle] = struct{}{}
+		handles = append(handles, handle)
+	}
+	return handles
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+const defaultMessageMaxLength = 1000
+
+type Conversation struct {
+	ID			uuid.UUID	`json:"id"`
+	CreatedAt	time.Time	`json:"created_at"`
+	UpdatedAt	time.Time	`json:"updated_at"`
+	OtherUser	User		`json:"other_user"`
+}
+
+type Message struct {
+	ID				uuid.UUID	`json:"id"`
+	CreatedAt		time.Time	`json:"created_at"`
+	ConversationID	uuid.UUID	`json:"conversation_id"`
+	SenderID		uuid.UUID	`json:"sender_id"`
+	Body			string		`json:"body"`
+}
+
+// handlerCreateConversation starts a conversation with another user, or
+// returns the existing one since there is only one per pair of users.
+func (cfg *apiConfig) handlerCreateConversation(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		UserID	uuid.UUID	`json:"user_id"`
+	}
+
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil || params.UserID == uuid.Nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if params.UserID == userID {
+		respondWithError(w, http.StatusBadRequest, "you cannot start a conversation with yourself")
+		return
+	}
+
+	otherUser, err := cfg.db.GetUserById(r.Context(), params.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		log.Printf("error retrieving user %s while creating conversation: %v", params.UserID, err)
+		return
+	}
+
+	if !cfg.requireUnblocked(w, r, userID, otherUser.ID) {
+		return
+	}
+
+	userA, userB := orderedParticipants(userID, otherUser.ID)
+	conversation, err := cfg.db.GetConversationByParticipants(r.Context(), database.GetConversationByParticipantsParams{
+		UserAID: userA,
+		UserBID: userB,
+	})
+	if err == nil {
+		respondWithJSON(w, http.StatusOK, databaseConversationToConversation(conversation, otherUser))
+		return
+	}
+	if err != sql.ErrNoRows {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving conversation")
+		log.Printf("error retrieving conversation between %s and %s: %v", userA, userB, err)
+		return
+	}
+
+	conversation, err = cfg.db.CreateConversation(r.Context(), database.CreateConversationParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserAID:   userA,
+		UserBID:   userB,
+	})
+	if err != nil {
+		// Lost a race with a concurrent request for the same pair.
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "conversation already exists")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error creating conversation")
+		log.Printf("error creating conversation between %s and %s: %v", userA, userB, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, databaseConversationToConversation(conversation, otherUser))
+}
+
+func (cfg *apiConfig) handlerGetConversations(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := cfg.db.GetConversationsForUser(r.Context(), database.GetConversationsForUserParams{
+		UserID:          userID,
+		BeforeUpdatedAt: page.before().Time,
+		BeforeID:        page.before().ID,
+		PageSize:        page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving conversations")
+		log.Printf("error retrieving conversations of user %s: %v", userID, err)
+		return
+	}
+
+	conversations := make([]Conversation, len(rows))
+	for i, row := range rows {
+		conversations[i] = databaseConversationToConversation(row.Conversation, row.User)
+	}
+	respondWithJSON(w, http.StatusOK, paginate(w, r, page, conversations, conversationCursor))
+}
+
+func (cfg *apiConfig) handlerGetMessages(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	conversation, ok := cfg.getParticipatingConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbMessages, err := cfg.db.GetConversationMessages(r.Context(), database.GetConversationMessagesParams{
+		ConversationID:  conversation.ID,
+		BeforeCreatedAt: page.before().Time,
+		BeforeID:        page.before().ID,
+		PageSize:        page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving messages")
+		log.Printf("error retrieving messages of conversation %s: %v", conversation.ID, err)
+		return
+	}
+
+	messages := make([]Message, len(dbMessages))
+	for i, dbMessage := range dbMessages {
+		messages[i] = databaseMessageToMessage(dbMessage)
+	}
+	respondWithJSON(w, http.StatusOK, paginate(w, r, page, messages, messageCursor))
+}
+
+func (cfg *apiConfig) handlerPostMessage(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body	string	`json:"body"`
+	}
+
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	conversation, ok := cfg.getParticipatingConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	otherUserID := conversation.UserAID
+	if otherUserID == userID {
+		otherUserID = conversation.UserBID
+	}
+	if !cfg.requireUnblocked(w, r, userID, otherUserID) {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		log.Printf("error decoding request payload while posting message: %v", err)
+		return
+	}
+
+	cleanedBody, err := cfg.validateAndCleanMessage(params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var message database.Message
+	err = cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		message, err = q.CreateMessage(r.Context(), database.CreateMessageParams{
+			ID:             uuid.New(),
+			CreatedAt:      time.Now().UTC(),
+			ConversationID: conversation.ID,
+			SenderID:       userID,
+			Body:           cleanedBody,
+		})
+		if err != nil {
+			return err
+		}
+		return q.TouchConversation(r.Context(), database.TouchConversationParams{
+			ID:        conversation.ID,
+			UpdatedAt: message.CreatedAt,
+		})
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error sending message")
+		log.Printf("error inserting message into conversation %s: %v", conversation.ID, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, databaseMessageToMessage(message))
+}
+
+// requireUnblocked checks that neither user blocks the other before userID
+// messages otherUserID, writing the error response itself when one does.
+// Conversations started before a block can still be read.
+func (cfg *apiConfig) requireUnblocked(w http.ResponseWriter, r *http.Request, userID, otherUserID uuid.UUID) bool {
+	blocked, err := cfg.isBlockedBetween(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, otherUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error checking blocks")
+		log.Printf("error checking blocks between %s and %s: %v", userID, otherUserID, err)
+		return false
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "you cannot message this user")
+		return false
+	}
+	return true
+}
+
+// getParticipatingConversation loads the {id} conversation. Conversations the
+// user is not part of are reported as not found.
+func (cfg *apiConfig) getParticipatingConversation(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.Conversation, bool) {
+	conversationID := r.PathValue("id")
+	uid, err := uuid.Parse(conversationID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid conversation ID")
+		return database.Conversation{}, false
+	}
+
+	conversation, err := cfg.db.GetConversation(r.Context(), uid)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "conversation not found")
+			return database.Conversation{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving conversation")
+		log.Printf("error retrieving conversation by id %s: %v", conversationID, err)
+		return database.Conversation{}, false
+	}
+
+	if conversation.UserAID != userID && conversation.UserBID != userID {
+		respondWithError(w, http.StatusNotFound, "conversation not found")
+		return database.Conversation{}, false
+	}
+
+	return conversation, true
+}
+
+func (cfg *apiConfig) validateAndCleanMessage(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("message body cannot be empty")
+	}
+	if utf8.RuneCountInString(body) > cfg.messageMaxLength {
+		return "", fmt.Errorf("message must be at most %d characters", cfg.messageMaxLength)
+	}
+
+	return maskBannedWords(body, cfg.bannedWords), nil
+}
+
+// orderedParticipants sorts two user IDs the way the conversations table
+// stores them, smaller first.
+func orderedParticipants(a, b uuid.UUID) (uuid.UUID, uuid.UUID) {
+	if bytes.Compare(a[:], b[:]) < 0 {
+		return a, b
+	}
+	return b, a
+}
+
+func databaseConversationToConversation(conversation database.Conversation, otherUser database.User) Conversation {
+	return Conversation{
+		ID:        conversation.ID,
+		CreatedAt: conversation.CreatedAt,
+		UpdatedAt: conversation.UpdatedAt,
+		OtherUser: databaseUserToUser(otherUser),
+	}
+}
+
+func databaseMessageToMessage(message database.Message) Message {
+	return Message{
+		ID:             message.ID,
+		CreatedAt:      message.CreatedAt,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		Body:           message.Body,
+	}
+}
+
+func conversationCursor(conversation Conversation) pageCursor {
+	return pageCursor{Time: conversation.UpdatedAt, ID: conversation.ID}
+}
+
+func messageCursor(message Message) pageCursor {
+	return pageCursor{Time: message.CreatedAt, ID: message.ID}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+// Notification types. The data payload of each type is described by the
+// matching struct below.
+const (
+	notificationNewLogin            = "new_login"
+	notificationRefreshTokenRevoked = "refresh_token_revoked"
+	notificationRefreshTokenReused  = "refresh_token_reused"
+	notificationChirpReadMilestone  = "chirp_read_milestone"
+)
+
+const defaultChirpReadThreshold = 100
+
+// maxUserAgentLength bounds the User-Agent kept for a login. Clients choose
+// it, and login_user_agents is keyed on it.
+const maxUserAgentLength = 512
+
+type newLoginData struct {
+	UserAgent	string	`json:"user_agent"`
+	IPAddress	string	`json:"ip_address"`
+}
+
+type refreshTokenRevokedData struct {
+	RevokedAt	time.Time	`json:"revoked_at"`
+}
+
+type refreshTokenReusedData struct {
+	FamilyID	uuid.UUID	`json:"family_id"`
+	DetectedAt	time.Time	`json:"detected_at"`
+}
+
+type chirpReadMilestoneData struct {
+	ChirpID		uuid.UUID	`json:"chirp_id"`
+	ReadCount	int64		`json:"read_count"`
+}
+
+type Notification struct {
+	ID			uuid.UUID		`json:"id"`
+	Type		string			`json:"type"`
+	Data		json.RawMessage	`json:"data"`
+	CreatedAt	time.Time		`json:"created_at"`
+	ReadAt		*time.Time		`json:"read_at"`
+}
+
+type notificationPage struct {
+	pageResponse[Notification]
+	UnreadCount	int64	`json:"unread_count"`
+}
+
+func (cfg *apiConfig) handlerGetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := cfg.db.GetNotifications(r.Context(), database.GetNotificationsParams{
+		UserID:          userID,
+		BeforeCreatedAt: page.before().Time,
+		BeforeID:        page.before().ID,
+		PageSize:        page.fetchSize(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving notifications")
+		log.Printf("error retrieving notifications of user %s: %v", userID, err)
+		return
+	}
+
+	unreadCount, err := cfg.db.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving notifications")
+		log.Printf("error counting unread notifications of user %s: %v", userID, err)
+		return
+	}
+
+	notifications := make([]Notification, len(rows))
+	for i, row := range rows {
+		notifications[i] = databaseNotificationToNotification(row)
+	}
+	respondWithJSON(w, http.StatusOK, notificationPage{
+		pageResponse: paginate(w, r, page, notifications, notificationCursor),
+		UnreadCount:  unreadCount,
+	})
+}
+
+// handlerMarkNotificationsRead marks the given notifications as read, or all of
+// them when no ids are sent.
+func (cfg *apiConfig) handlerMarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		IDs	[]uuid.UUID	`json:"ids"`
+	}
+
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	params := parameters{}
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&params)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid request body")
+			log.Printf("error decoding request payload while marking notifications read: %v", err)
+			return
+		}
+	}
+
+	readAt := sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	if len(params.IDs) == 0 {
+		_, err = cfg.db.MarkAllNotificationsRead(r.Context(), database.MarkAllNotificationsReadParams{
+			UserID: userID,
+			ReadAt: readAt,
+		})
+	} else {
+		_, err = cfg.db.MarkNotificationsRead(r.Context(), database.MarkNotificationsReadParams{
+			ReadAt: readAt,
+			UserID: userID,
+			Ids:    params.IDs,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error marking notifications read")
+		log.Printf("error marking notifications read for user %s: %v", userID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createNotification stores a notification of the given type for userID. It
+// takes a Queries so callers can write it in the same transaction as the
+// action that triggered it.
+func createNotification(ctx context.Context, q *database.Queries, userID uuid.UUID, notificationType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return q.CreateNotification(ctx, database.CreateNotificationParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    userID,
+		Type:      notificationType,
+		Data:      payload,
+	})
+}
+
+// notifyIfNewUserAgent records the user agent of a login and raises a
+// new_login notification the first time it is seen for an account that has
+// logged in before.
+func notifyIfNewUserAgent(ctx context.Context, q *database.Queries, userID uuid.UUID, r *http.Request) error {
+	isNew, err := q.RecordLoginUserAgent(ctx, database.RecordLoginUserAgentParams{
+		UserID:    userID,
+		UserAgent: clientUserAgent(r),
+		SeenAt:    time.Now().UTC(),
+	})
+	if err != nil || !isNew {
+		return err
+	}
+
+	knownAgents, err := q.CountLoginUserAgents(ctx, userID)
+	if err != nil || knownAgents <= 1 {
+		return err
+	}
+
+	return createNotification(ctx, q, userID, notificationNewLogin, newLoginData{
+		UserAgent: clientUserAgent(r),
+		IPAddress: clientIP(r),
+	})
+}
+
+// recordChirpRead counts a read of chirp and notifies its author when the
+// count reaches the configured threshold. Authors reading their own chirps
+// are not counted.
+func (cfg *apiConfig) recordChirpRead(ctx context.Context, chirp Chirp, viewerID uuid.NullUUID) error {
+	if cfg.chirpReadThreshold <= 0 || (viewerID.Valid && viewerID.UUID == chirp.UserID) {
+		return nil
+	}
+
+	// The increment locks the counter row until commit, so exactly one read
+	// sees the threshold. The notification is written in the same transaction:
+	// if it fails the read is not counted and the milestone is not lost.
+	return cfg.db.ExecTx(ctx, func(q *database.Queries) error {
+		readCount, err := q.IncrementChirpReadCount(ctx, chirp.ID)
+		if err != nil || readCount != cfg.chirpReadThreshold {
+			return err
+		}
+
+		return createNotification(ctx, q, chirp.UserID, notificationChirpReadMilestone, chirpReadMilestoneData{
+			ChirpID:   chirp.ID,
+			ReadCount: readCount,
+		})
+	})
+}
+
+func databaseNotificationToNotification(notification database.Notification) Notification {
+	result := Notification{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		Data:      notification.Data,
+		CreatedAt: notification.CreatedAt,
+	}
+	if notification.ReadAt.Valid {
+		result.ReadAt = &notification.ReadAt.Time
+	}
+	return result
+}
+
+func notificationCursor(notification Notification) pageCursor {
+	return pageCursor{Time: notification.CreatedAt, ID: notification.ID}
+}
+
+// clientUserAgent returns the User-Agent of r, cut to maxUserAgentLength
+// bytes without splitting a character.
+func clientUserAgent(r *http.Request) string {
+	userAgent := r.UserAgent()
+	if len(userAgent) <= maxUserAgentLength {
+		return userAgent
+	}
+	return strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestClientUserAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{name: "short", userAgent: "curl/8.0", want: "curl/8.0"},
+		{name: "at the limit", userAgent: strings.Repeat("a", maxUserAgentLength), want: strings.Repeat("a", maxUserAgentLength)},
+		{name: "too long", userAgent: strings.Repeat("a", 10000), want: strings.Repeat("a", maxUserAgentLength)},
+		// "é" is two bytes; the cut falls inside the last one.
+		{name: "multibyte", userAgent: "a" + strings.Repeat("é", maxUserAgentLength), want: "a" + strings.Repeat("é", maxUserAgentLength/2-1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/login", nil)
+			r.Header.Set("User-Agent", tt.userAgent)
+			got := clientUserAgent(r)
+			if got != tt.want {
+				t.Errorf("clientUserAgent = %d bytes, want %d", len(got), len(tt.want))
+			}
+			if !utf8.ValidString(got) {
+				t.Error("clientUserAgent split a character")
+			}
+		})
+	}
+}
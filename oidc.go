@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/auth"
+	"github.com/leonardomlouzas/GOose/internal/database"
+	"github.com/leonardomlouzas/GOose/internal/oidc"
+)
+
+const oidcStateDuration = 10 * time.Minute
+const oidcStateCookie = "oidc_state"
+const oidcHandleAttempts = 5
+
+var nonHandleCharacters = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+var errUnverifiedAccount = errors.New("account email not verified")
+
+// handlerOIDCLogin sends the user to the identity provider. The state is
+// stored server side with the nonce and PKCE verifier, and bound to the
+// browser with a cookie.
+func (cfg *apiConfig) handlerOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if cfg.oidcProvider == nil {
+		respondWithError(w, http.StatusNotFound, "single sign-on is not configured")
+		return
+	}
+
+	state, err := auth.MakeRandomToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error starting sign-in")
+		log.Printf("error generating oidc state: %v", err)
+		return
+	}
+	nonce, err := auth.MakeRandomToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error starting sign-in")
+		log.Printf("error generating oidc nonce: %v", err)
+		return
+	}
+	codeVerifier, err := auth.MakeRandomToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error starting sign-in")
+		log.Printf("error generating oidc code verifier: %v", err)
+		return
+	}
+
+	authURL, err := cfg.oidcProvider.AuthCodeURL(r.Context(), state, nonce, codeVerifier)
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "identity provider unavailable")
+		log.Printf("error building oidc authorization url: %v", err)
+		return
+	}
+
+	// Sign-ins that were never finished are cleaned up by later ones.
+	if err := cfg.db.DeleteExpiredOIDCLoginStates(r.Context(), time.Now().UTC()); err != nil {
+		log.Printf("error deleting expired oidc login states: %v", err)
+	}
+
+	err = cfg.db.CreateOIDCLoginState(r.Context(), database.CreateOIDCLoginStateParams{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		CreatedAt:    time.Now().UTC(),
+		ExpiresAt:    time.Now().UTC().Add(oidcStateDuration),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error starting sign-in")
+		log.Printf("error saving oidc login state: %v", err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/api/oidc",
+		MaxAge:   int(oidcStateDuration.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(cfg.publicURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// handlerOIDCCallback finishes the sign-in started by handlerOIDCLogin and
+// responds like handlerLoginByPassword.
+func (cfg *apiConfig) handlerOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if cfg.oidcProvider == nil {
+		respondWithError(w, http.StatusNotFound, "single sign-on is not configured")
+		return
+	}
+
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		respondWithError(w, http.StatusUnauthorized, "sign-in was refused by the identity provider")
+		log.Printf("oidc provider returned error %q: %s", providerError, query.Get("error_description"))
+		return
+	}
+
+	state := query.Get("state")
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || state == "" || cookie.Value != state {
+		respondWithError(w, http.StatusBadRequest, "invalid state")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:   oidcStateCookie,
+		Path:   "/api/oidc",
+		MaxAge: -1,
+	})
+
+	loginState, err := cfg.db.ConsumeOIDCLoginState(r.Context(), database.ConsumeOIDCLoginStateParams{
+		State:     state,
+		ExpiresAt: time.Now().UTC(),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusBadRequest, "invalid or expired state")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error completing sign-in")
+		log.Printf("error retrieving oidc login state: %v", err)
+		return
+	}
+
+	rawIDToken, err := cfg.oidcProvider.Exchange(r.Context(), query.Get("code"), loginState.CodeVerifier)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "could not complete sign-in")
+		log.Printf("error exchanging oidc authorization code: %v", err)
+		return
+	}
+
+	claims, err := cfg.oidcProvider.VerifyIDToken(r.Context(), rawIDToken, loginState.Nonce)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid ID token")
+		log.Printf("error verifying oidc id token: %v", err)
+		return
+	}
+
+	user, ok := cfg.findOrCreateOIDCUser(w, r, claims)
+	if !ok {
+		return
+	}
+
+	cfg.completeLogin(w, r, user)
+}
+
+// findOrCreateOIDCUser returns the user linked to the identity in claims. An
+// identity seen for the first time is linked to the account with the same
+// email, or to a new account, but only if the provider verified the email.
+// An existing account must have verified it too: anyone can sign up with an
+// address they do not own, and linking would hand them the owner's sign-ins.
+func (cfg *apiConfig) findOrCreateOIDCUser(w http.ResponseWriter, r *http.Request, claims *oidc.Claims) (database.User, bool) {
+	issuer := cfg.oidcProvider.Issuer()
+	user, err := cfg.db.GetUserByIdentity(r.Context(), database.GetUserByIdentityParams{
+		Issuer:  issuer,
+		Subject: claims.Subject,
+	})
+	if err == nil {
+		return user, true
+	}
+	if err != sql.ErrNoRows {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		log.Printf("error retrieving user by oidc identity %s: %v", claims.Subject, err)
+		return database.User{}, false
+	}
+
+	email := strings.TrimSpace(claims.Email)
+	if email == "" || !claims.EmailVerified {
+		respondWithError(w, http.StatusForbidden, "the identity provider did not return a verified email address")
+		return database.User{}, false
+	}
+
+	now := time.Now().UTC()
+	err = cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		created := false
+		user, err = q.GetUserByEmail(r.Context(), email)
+		if err == sql.ErrNoRows {
+			user, err = createOIDCUser(r.Context(), q, email)
+			created = true
+		}
+		if err != nil {
+			return err
+		}
+		if !created && !user.EmailVerifiedAt.Valid {
+			return errUnverifiedAccount
+		}
+
+		err = q.CreateUserIdentity(r.Context(), database.CreateUserIdentityParams{
+			Issuer:    issuer,
+			Subject:   claims.Subject,
+			UserID:    user.ID,
+			Email:     email,
+			CreatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+
+		if created {
+			_, err = q.MarkEmailVerified(r.Context(), database.MarkEmailVerifiedParams{
+				ID:              user.ID,
+				Email:           user.Email,
+				EmailVerifiedAt: sql.NullTime{Time: now, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+			user.EmailVerifiedAt = sql.NullTime{Time: now, Valid: true}
+		}
+		return nil
+	})
+	if err != nil {
+		if err == errUnverifiedAccount {
+			respondWithError(w, http.StatusConflict, "an account with this email exists but has not verified it, log in with its password and verify the email first")
+			return database.User{}, false
+		}
+		// A concurrent sign-in with the same identity or email won the race.
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "account is being linked, please try again")
+			return database.User{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "error linking account")
+		log.Printf("error linking oidc identity %s to %s: %v", claims.Subject, email, err)
+		return database.User{}, false
+	}
+
+	return user, true
+}
+
+// createOIDCUser creates an account for someone signing in with the identity
+// provider for the first time. It gets a handle derived from the email and a
+// random password that nobody knows; a password can be set through a reset.
+func createOIDCUser(ctx context.Context, q *database.Queries, email string) (database.User, error) {
+	handle, err := availableHandle(ctx, q, email)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	password, err := auth.MakeRandomToken()
+	if err != nil {
+		return database.User{}, err
+	}
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	return q.CreateUser(ctx, database.CreateUserParams{
+		ID:             uuid.New(),
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+		Email:          email,
+		HashedPassword: hashedPassword,
+		Handle:         handle,
+	})
+}
+
+// availableHandle derives a free handle from the local part of email, adding
+// a random suffix when it is taken.
+func availableHandle(ctx context.Context, q *database.Queries, email string) (string, error) {
+	base := nonHandleCharacters.ReplaceAllString(strings.SplitN(email, "@", 2)[0], "")
+	if len(base) > 10 {
+		base = base[:10]
+	}
+	if len(base) < 3 {
+		base = "user" + base
+	}
+
+	handle := base
+	for i := 0; i < oidcHandleAttempts; i++ {
+		_, err := q.GetUserByHandle(ctx, handle)
+		if err == sql.ErrNoRows {
+			return handle, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		handle = fmt.Sprintf("%s%04d", base, rand.IntN(10000))
+	}
+	return "", fmt.Errorf("no available handle for %s", email)
+}
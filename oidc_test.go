@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leonardomlouzas/GOose/internal/database"
+	"github.com/leonardomlouzas/GOose/internal/oidc"
+	"github.com/leonardomlouzas/GOose/internal/oidc/oidctest"
+)
+
+// newTestOIDCConfig returns a test config signing in through a mock identity
+// provider.
+func newTestOIDCConfig(t *testing.T) (*apiConfig, *oidctest.Server) {
+	t.Helper()
+
+	cfg, _ := newTestConfig(t)
+	idp := oidctest.NewServer("chirpy")
+	t.Cleanup(idp.Close)
+	cfg.oidcProvider = oidc.NewProvider(oidc.Config{
+		Issuer:      idp.Issuer(),
+		ClientID:    idp.ClientID,
+		RedirectURL: cfg.publicURL + "/api/oidc/callback",
+	}, idp.Client())
+	return cfg, idp
+}
+
+// signInWithOIDC runs handlerOIDCLogin, lets modify adjust the claims the
+// provider returns for subject and email, and runs handlerOIDCCallback with
+// the resulting code.
+func signInWithOIDC(t *testing.T, cfg *apiConfig, idp *oidctest.Server, subject, email string, modify func(claims jwt.MapClaims)) *httptest.ResponseRecorder {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	cfg.handlerOIDCLogin(w, httptest.NewRequest(http.MethodGet, "/api/oidc/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login status = %d, want %d: %s", w.Code, http.StatusFound, w.Body)
+	}
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parsing authorization url: %v", err)
+	}
+	state := authURL.Query().Get("state")
+
+	claims := idp.Claims(subject, authURL.Query().Get("nonce"), email)
+	if modify != nil {
+		modify(claims)
+	}
+	code := idp.IssueCode(claims)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/oidc/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+	for _, cookie := range w.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	cfg.handlerOIDCCallback(w, r)
+	return w
+}
+
+func decodeUser(t *testing.T, w *httptest.ResponseRecorder) User {
+	t.Helper()
+
+	user := User{}
+	if err := json.NewDecoder(w.Body).Decode(&user); err != nil {
+		t.Fatalf("decoding user: %v", err)
+	}
+	return user
+}
+
+func TestOIDCCallbackCreatesUser(t *testing.T) {
+	cfg, idp := newTestOIDCConfig(t)
+
+	w := signInWithOIDC(t, cfg, idp, "subject-1", "walt@example.com", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	user := decodeUser(t, w)
+	if user.Email != "walt@example.com" || user.Handle != "walt" || user.Token == "" || user.RefreshToken == "" {
+		t.Errorf("user = %+v", user)
+	}
+	if user.EmailVerified == nil || !*user.EmailVerified {
+		t.Error("email of the new account is not verified")
+	}
+
+	w = signInWithOIDC(t, cfg, idp, "subject-1", "walt@example.com", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("second sign-in status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if again := decodeUser(t, w); again.ID != user.ID {
+		t.Errorf("second sign-in returned user %s, want %s", again.ID, user.ID)
+	}
+}
+
+// createVerifiedTestUser is createTestUser for an account that verified its
+// email.
+func createVerifiedTestUser(t *testing.T, cfg *apiConfig, email, password string) database.User {
+	t.Helper()
+
+	user := createTestUser(t, cfg, email, password)
+	user.EmailVerifiedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	_, err := cfg.db.MarkEmailVerified(context.Background(), database.MarkEmailVerifiedParams{
+		ID:              user.ID,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+	})
+	if err != nil {
+		t.Fatalf("MarkEmailVerified: %v", err)
+	}
+	return user
+}
+
+func TestOIDCCallbackLinksExistingAccount(t *testing.T) {
+	cfg, idp := newTestOIDCConfig(t)
+	existing := createVerifiedTestUser(t, cfg, "walt@example.com", "password")
+
+	w := signInWithOIDC(t, cfg, idp, "subject-1", "walt@example.com", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if user := decodeUser(t, w); user.ID != existing.ID {
+		t.Fatalf("signed in as %s, want the existing account %s", user.ID, existing.ID)
+	}
+
+	linked, err := cfg.db.GetUserByIdentity(context.Background(), database.GetUserByIdentityParams{
+		Issuer:  idp.Issuer(),
+		Subject: "subject-1",
+	})
+	if err != nil {
+		t.Fatalf("GetUserByIdentity: %v", err)
+	}
+	if linked.ID != existing.ID {
+		t.Errorf("identity linked to %s, want %s", linked.ID, existing.ID)
+	}
+
+	// Once linked, the identity keeps signing in to the account even if the
+	// provider reports another email.
+	w = signInWithOIDC(t, cfg, idp, "subject-1", "heisenberg@example.com", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("second sign-in status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if user := decodeUser(t, w); user.ID != existing.ID {
+		t.Errorf("second sign-in returned user %s, want %s", user.ID, existing.ID)
+	}
+}
+
+// TestOIDCCallbackUnverifiedAccount covers someone registering an address
+// they do not own before its owner first signs in with the identity provider.
+func TestOIDCCallbackUnverifiedAccount(t *testing.T) {
+	cfg, idp := newTestOIDCConfig(t)
+	squatter := createTestUser(t, cfg, "walt@example.com", "attacker's password")
+
+	w := signInWithOIDC(t, cfg, idp, "subject-1", "walt@example.com", nil)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body)
+	}
+
+	_, err := cfg.db.GetUserByIdentity(context.Background(), database.GetUserByIdentityParams{
+		Issuer:  idp.Issuer(),
+		Subject: "subject-1",
+	})
+	if err != sql.ErrNoRows {
+		t.Errorf("the identity was linked to the unverified account %s: %v", squatter.ID, err)
+	}
+	user, err := cfg.db.GetUserByEmail(context.Background(), "walt@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+	if user.EmailVerifiedAt.Valid {
+		t.Error("the email of the unverified account was marked as verified")
+	}
+}
+
+func TestOIDCCallbackUnverifiedEmail(t *testing.T) {
+	cfg, idp := newTestOIDCConfig(t)
+	existing := createTestUser(t, cfg, "walt@example.com", "password")
+
+	for _, email := range []string{"walt@example.com", "jesse@example.com"} {
+		w := signInWithOIDC(t, cfg, idp, "subject-"+email, email, func(claims jwt.MapClaims) {
+			claims["email_verified"] = false
+		})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want %d", email, w.Code, http.StatusForbidden)
+		}
+	}
+
+	if _, err := cfg.db.GetUserByEmail(context.Background(), "jesse@example.com"); err != sql.ErrNoRows {
+		t.Errorf("an account was created for an unverified email: %v", err)
+	}
+	_, err := cfg.db.GetUserByIdentity(context.Background(), database.GetUserByIdentityParams{
+		Issuer:  idp.Issuer(),
+		Subject: "subject-walt@example.com",
+	})
+	if err != sql.ErrNoRows {
+		t.Errorf("an unverified email was linked to account %s: %v", existing.ID, err)
+	}
+}
+
+func TestOIDCCallbackRejectsInvalidIDToken(t *testing.T) {
+	cfg, idp := newTestOIDCConfig(t)
+
+	tests := []struct {
+		name   string
+		modify func(claims jwt.MapClaims)
+	}{
+		{
+			name:   "wrong nonce",
+			modify: func(claims jwt.MapClaims) { claims["nonce"] = "another-nonce" },
+		},
+		{
+			name:   "wrong audience",
+			modify: func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := signInWithOIDC(t, cfg, idp, "subject-1", "walt@example.com", tt.modify)
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackStateMismatch(t *testing.T) {
+	cfg, idp := newTestOIDCConfig(t)
+
+	w := httptest.NewRecorder()
+	cfg.handlerOIDCLogin(w, httptest.NewRequest(http.MethodGet, "/api/oidc/login", nil))
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parsing authorization url: %v", err)
+	}
+	code := idp.IssueCode(idp.Claims("subject-1", authURL.Query().Get("nonce"), "walt@example.com"))
+
+	// The callback comes without the cookie set at login, as when a link
+	// started in another browser is opened.
+	r := httptest.NewRequest(http.MethodGet, "/api/oidc/callback?"+url.Values{"code": {code}, "state": {authURL.Query().Get("state")}}.Encode(), nil)
+	w = httptest.NewRecorder()
+	cfg.handlerOIDCCallback(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
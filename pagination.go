@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultPageSize = 20
+const maxPageSize = 100
+
+// pageCursor is the keyset position of the last item of a page. Clients only
+// ever see it as an opaque string.
+type pageCursor struct {
+	Time time.Time
+	ID   uuid.UUID
+}
+
+type pageParams struct {
+	Limit  int32
+	Cursor *pageCursor
+}
+
+type pageResponse[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	raw := cursor.Time.UTC().Format(time.RFC3339Nano) + "," + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, err
+	}
+
+	timePart, idPart, found := strings.Cut(string(raw), ",")
+	if !found {
+		return pageCursor{}, fmt.Errorf("malformed cursor")
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, timePart)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	return pageCursor{Time: t, ID: id}, nil
+}
+
+// parsePageParams reads the limit and cursor query parameters of a listing request.
+func parsePageParams(r *http.Request) (pageParams, error) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		return pageParams{}, err
+	}
+	params := pageParams{Limit: limit}
+
+	if rawCursor := r.URL.Query().Get("cursor"); rawCursor != "" {
+		cursor, err := decodeCursor(rawCursor)
+		if err != nil {
+			return pageParams{}, fmt.Errorf("invalid cursor")
+		}
+		params.Cursor = &cursor
+	}
+
+	return params, nil
+}
+
+func parseLimit(r *http.Request) (int32, error) {
+	rawLimit := r.URL.Query().Get("limit")
+	if rawLimit == "" {
+		return defaultPageSize, nil
+	}
+
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+	return int32(limit), nil
+}
+
+// fetchSize asks the database for one extra row so we know whether a next page exists.
+func (p pageParams) fetchSize() int32 {
+	return p.Limit + 1
+}
+
+// after returns the position an ascending listing resumes after. Without a
+// cursor it is below every row, so that queries can always use a plain row
+// comparison, which the (created_at, id) indexes serve.
+func (p pageParams) after() pageCursor {
+	if p.Cursor == nil {
+		return pageCursor{Time: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), ID: uuid.Nil}
+	}
+	return *p.Cursor
+}
+
+// before is after for descending listings.
+func (p pageParams) before() pageCursor {
+	if p.Cursor == nil {
+		return pageCursor{Time: time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC), ID: uuid.Max}
+	}
+	return *p.Cursor
+}
+
+// paginate trims items fetched with fetchSize down to the requested limit and,
+// when there is more to read, sets the next cursor and an RFC 8288 Link header.
+func paginate[T any](w http.ResponseWriter, r *http.Request, params pageParams, items []T, cursorOf func(T) pageCursor) pageResponse[T] {
+	if len(items) <= int(params.Limit) {
+		return pageResponse[T]{Items: items}
+	}
+
+	items = items[:params.Limit]
+	nextCursor := encodeCursor(cursorOf(items[len(items)-1]))
+	setNextLink(w, r, nextCursor, params.Limit)
+
+	return pageResponse[T]{Items: items, NextCursor: &nextCursor}
+}
+
+// Ranked results have no stable keyset to resume from, so their cursor wraps
+// a plain offset instead.
+type offsetPageParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func encodeOffsetCursor(offset int32) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset," + strconv.Itoa(int(offset))))
+}
+
+func decodeOffsetCursor(s string) (int32, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, err
+	}
+
+	rawOffset, found := strings.CutPrefix(string(raw), "offset,")
+	if !found {
+		return 0, fmt.Errorf("malformed cursor")
+	}
+
+	offset, err := strconv.ParseInt(rawOffset, 10, 32)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("malformed cursor")
+	}
+	return int32(offset), nil
+}
+
+func parseOffsetPageParams(r *http.Request) (offsetPageParams, error) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		return offsetPageParams{}, err
+	}
+	params := offsetPageParams{Limit: limit}
+
+	if rawCursor := r.URL.Query().Get("cursor"); rawCursor != "" {
+		offset, err := decodeOffsetCursor(rawCursor)
+		if err != nil {
+			return offsetPageParams{}, fmt.Errorf("invalid cursor")
+		}
+		params.Offset = offset
+	}
+
+	return params, nil
+}
+
+func (p offsetPageParams) fetchSize() int32 {
+	return p.Limit + 1
+}
+
+// paginateByOffset is paginate for items fetched with an offsetPageParams.
+func paginateByOffset[T any](w http.ResponseWriter, r *http.Request, params offsetPageParams, items []T) pageResponse[T] {
+	if len(items) <= int(params.Limit) {
+		return pageResponse[T]{Items: items}
+	}
+
+	items = items[:params.Limit]
+	nextCursor := encodeOffsetCursor(params.Offset + params.Limit)
+	setNextLink(w, r, nextCursor, params.Limit)
+
+	return pageResponse[T]{Items: items, NextCursor: &nextCursor}
+}
+
+func setNextLink(w http.ResponseWriter, r *http.Request, nextCursor string, limit int32) {
+	nextURL := *r.URL
+	query := nextURL.Query()
+	query.Set("cursor", nextCursor)
+	query.Set("limit", strconv.Itoa(int(limit)))
+	nextURL.RawQuery = query.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, nextURL.RequestURI()))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestDecodeOffsetCursor(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		want    int32
+		wantErr bool
+	}{
+		{name: "round trip", cursor: encodeOffsetCursor(40), want: 40},
+		{name: "largest offset", cursor: encode("offset,2147483647"), want: 2147483647},
+		{name: "beyond int32", cursor: encode("offset,2147483648"), wantErr: true},
+		{name: "beyond int64", cursor: encode("offset,99999999999999999999"), wantErr: true},
+		{name: "negative", cursor: encode("offset,-1"), wantErr: true},
+		{name: "not a number", cursor: encode("offset,ten"), wantErr: true},
+		{name: "keyset cursor", cursor: encode("2024-01-01T00:00:00Z,abc"), wantErr: true},
+		{name: "not base64", cursor: "!!!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeOffsetCursor(tt.cursor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeOffsetCursor error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("decodeOffsetCursor = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
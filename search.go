@@ -0,0 +1,86 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+type SearchResult struct {
+	Chirp
+	Rank	float32	`json:"rank"`
+	// Snippet is an excerpt of the body around the matches, HTML-escaped,
+	// with the matches wrapped in <mark> tags.
+	Snippet	string	`json:"snippet"`
+}
+
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.optionalUserID(r, scopeChirpsRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondWithError(w, http.StatusBadRequest, "search query cannot be empty")
+		return
+	}
+
+	page, err := parseOffsetPageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query = removeBannedSearchTerms(query, cfg.bannedWords)
+	if query == "" {
+		respondWithJSON(w, http.StatusOK, pageResponse[SearchResult]{Items: []SearchResult{}})
+		return
+	}
+
+	rows, err := cfg.db.SearchChirps(r.Context(), database.SearchChirpsParams{
+		ViewerID:   viewerID,
+		Query:      query,
+		PageSize:   page.fetchSize(),
+		PageOffset: page.Offset,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error searching chirps")
+		log.Printf("error searching chirps for %q: %v", query, err)
+		return
+	}
+
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = SearchResult{
+			Chirp: databaseChirpRowToChirp(chirpRow{
+				ChirpListing: row.ChirpListing,
+				LikedByMe:    row.LikedByMe,
+			}, viewerID),
+			Rank:    row.Rank,
+			Snippet: row.Snippet,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, paginateByOffset(w, r, page, results))
+}
+
+// removeBannedSearchTerms drops banned words and their "****" mask from a
+// search query, so masked words can never be looked up.
+func removeBannedSearchTerms(query string, bannedWords map[string]struct{}) string {
+	terms := strings.Fields(query)
+	kept := make([]string, 0, len(terms))
+	for _, term := range terms {
+		word := strings.ToLower(strings.Trim(term, `"-*`))
+		if word == "" {
+			continue
+		}
+		if _, ok := bannedWords[word]; ok {
+			continue
+		}
+		kept = append(kept, term)
+	}
+	return strings.Join(kept, " ")
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+// sessionTouchInterval limits how often last_used_at is written for a session
+// in constant use.
+const sessionTouchInterval = time.Minute
+
+// Session is one login, identified by the family of refresh tokens rotated
+// from it. It is used whenever it refreshes or one of its access tokens is
+// accepted.
+type Session struct {
+	ID			uuid.UUID	`json:"id"`
+	CreatedAt	time.Time	`json:"created_at"`
+	LastUsedAt	time.Time	`json:"last_used_at"`
+	ExpiresAt	time.Time	`json:"expires_at"`
+	UserAgent	string		`json:"user_agent"`
+	IPAddress	string		`json:"ip_address"`
+}
+
+func (cfg *apiConfig) handlerGetSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	rows, err := cfg.db.GetSessionsForUser(r.Context(), database.GetSessionsForUserParams{
+		UserID: userID,
+		Now:    time.Now().UTC(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving sessions")
+		log.Printf("error retrieving sessions of user %s: %v", userID, err)
+		return
+	}
+
+	sessions := make([]Session, len(rows))
+	for i, row := range rows {
+		sessions[i] = Session{
+			ID:         row.FamilyID,
+			CreatedAt:  row.StartedAt,
+			LastUsedAt: row.LastUsedAt,
+			ExpiresAt:  row.ExpiresAt,
+			UserAgent:  row.UserAgent,
+			IPAddress:  row.IpAddress,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, sessions)
+}
+
+// touchSession records that sessionID was just used. Failing to is only
+// logged: it must not fail the request.
+func (cfg *apiConfig) touchSession(ctx context.Context, sessionID uuid.UUID) {
+	now := time.Now().UTC()
+	err := cfg.db.TouchSession(ctx, database.TouchSessionParams{
+		Now:        now,
+		FamilyID:   sessionID,
+		UsedBefore: now.Add(-sessionTouchInterval),
+	})
+	if err != nil {
+		log.Printf("error updating last use of session %s: %v", sessionID, err)
+	}
+}
+
+// handlerRevokeSession logs the {id} session out by revoking its refresh
+// token. Access tokens already issued to it stay valid until they expire, and
+// its next refresh is refused without affecting the other sessions.
+func (cfg *apiConfig) handlerRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	sessionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid session ID")
+		return
+	}
+
+	revoked, err := cfg.db.RevokeRefreshTokenFamily(r.Context(), database.RevokeRefreshTokenFamilyParams{
+		UserID:   userID,
+		FamilyID: sessionID,
+		RevokedAt: sql.NullTime{
+			Time:  time.Now().UTC(),
+			Valid: true,
+		},
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error revoking session")
+		log.Printf("error revoking session %s of user %s: %v", sessionID, userID, err)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	err = cfg.db.RevokeAllRefreshTokensForUser(r.Context(), database.RevokeAllRefreshTokensForUserParams{
+		UserID: userID,
+		RevokedAt: sql.NullTime{
+			Time:  time.Now().UTC(),
+			Valid: true,
+		},
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error revoking sessions")
+		log.Printf("error revoking sessions of user %s: %v", userID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
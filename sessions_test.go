@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/auth"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+func TestSessionLastUsed(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com", "password")
+
+	familyID := uuid.New()
+	loggedInAt := time.Now().UTC().Add(-time.Hour).Truncate(time.Microsecond)
+	_, err := cfg.db.InsertRefreshTokenIntoDB(context.Background(), database.InsertRefreshTokenIntoDBParams{
+		Token:     "refresh-token",
+		CreatedAt: loggedInAt,
+		UpdatedAt: loggedInAt,
+		UserID:    user.ID,
+		ExpiresAt: loggedInAt.Add(refreshTokenDuration),
+		FamilyID:  familyID,
+	})
+	if err != nil {
+		t.Fatalf("inserting refresh token: %v", err)
+	}
+
+	token, err := auth.MakeJWT(user.ID, familyID, cfg.keyring, time.Hour)
+	if err != nil {
+		t.Fatalf("making access token: %v", err)
+	}
+	getSessions := func() []Session {
+		t.Helper()
+
+		r := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		cfg.handlerGetSessions(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+		}
+		sessions := []Session{}
+		if err := json.NewDecoder(w.Body).Decode(&sessions); err != nil {
+			t.Fatalf("decoding sessions: %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].ID != familyID {
+			t.Fatalf("sessions = %+v, want only %s", sessions, familyID)
+		}
+		return sessions
+	}
+
+	// Listing the sessions is itself a use of the one asking.
+	first := getSessions()[0]
+	if !first.LastUsedAt.After(loggedInAt.Add(sessionTouchInterval)) {
+		t.Errorf("last used at %s, want about now", first.LastUsedAt)
+	}
+	if !first.CreatedAt.Equal(loggedInAt) {
+		t.Errorf("created at %s, want %s", first.CreatedAt, loggedInAt)
+	}
+
+	// Uses in quick succession are not all written.
+	if again := getSessions()[0]; !again.LastUsedAt.Equal(first.LastUsedAt) {
+		t.Errorf("last use moved from %s to %s within %s", first.LastUsedAt, again.LastUsedAt, sessionTouchInterval)
+	}
+}
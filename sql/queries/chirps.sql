@@ -1,15 +1,128 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, reply_to_id, repost_of_id, quote_of_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
 -- name: GetAllChirps :many
-SELECT * FROM chirps
-ORDER BY created_at;
+SELECT sqlc.embed(chirp_listings),
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = sqlc.narg('viewer_id')::uuid) AS liked_by_me
+FROM chirp_listings
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR chirp_listings.user_id = sqlc.narg('author_id')::uuid)
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = sqlc.narg('viewer_id')::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+    AND (chirp_listings.created_at, chirp_listings.id) > (@after_created_at::timestamp, @after_id::uuid)
+ORDER BY chirp_listings.created_at, chirp_listings.id
+LIMIT @page_size;
+
+-- name: GetAllChirpsDesc :many
+SELECT sqlc.embed(chirp_listings),
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = sqlc.narg('viewer_id')::uuid) AS liked_by_me
+FROM chirp_listings
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR chirp_listings.user_id = sqlc.narg('author_id')::uuid)
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = sqlc.narg('viewer_id')::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+    AND (chirp_listings.created_at, chirp_listings.id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY chirp_listings.created_at DESC, chirp_listings.id DESC
+LIMIT @page_size;
 
 -- name: GetOneChirp :one
-SELECT * FROM chirps
+SELECT sqlc.embed(chirp_listings),
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = sqlc.narg('viewer_id')::uuid) AS liked_by_me
+FROM chirp_listings
+WHERE chirp_listings.id = @id
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = sqlc.narg('viewer_id')::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+            AND NOT hidden_users.muted
+    );
+
+-- name: IncrementChirpReadCount :one
+INSERT INTO chirp_read_counts (chirp_id, read_count)
+VALUES ($1, 1)
+ON CONFLICT (chirp_id) DO UPDATE SET read_count = chirp_read_counts.read_count + 1
+RETURNING read_count;
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors (id, depth) AS (
+    SELECT c.reply_to_id, 1 FROM chirps AS c
+    WHERE c.id = @id AND c.reply_to_id IS NOT NULL
+    UNION ALL
+    SELECT c.reply_to_id, ancestors.depth + 1 FROM chirps AS c
+    JOIN ancestors ON c.id = ancestors.id
+    WHERE c.reply_to_id IS NOT NULL
+)
+SELECT sqlc.embed(chirp_listings),
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = sqlc.narg('viewer_id')::uuid) AS liked_by_me
+FROM chirp_listings
+JOIN ancestors ON chirp_listings.id = ancestors.id
+WHERE NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = sqlc.narg('viewer_id')::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+ORDER BY ancestors.depth DESC;
+
+-- name: GetChirpReplies :many
+SELECT sqlc.embed(chirp_listings),
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = sqlc.narg('viewer_id')::uuid) AS liked_by_me
+FROM chirp_listings
+WHERE chirp_listings.reply_to_id = @chirp_id
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = sqlc.narg('viewer_id')::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+    AND (chirp_listings.created_at, chirp_listings.id) > (@after_created_at::timestamp, @after_id::uuid)
+ORDER BY chirp_listings.created_at, chirp_listings.id
+LIMIT @page_size;
+
+-- name: DeleteChirp :exec
+DELETE FROM chirps
 WHERE id = $1;
 
+-- name: DeleteRepostsOf :many
+DELETE FROM chirps
+WHERE repost_of_id = $1
+RETURNING *;
+
+-- name: DeleteRepost :one
+WITH deleted AS (
+    DELETE FROM chirps
+    WHERE chirps.user_id = @user_id
+        AND chirps.repost_of_id = (
+            SELECT COALESCE(target.repost_of_id, target.id) FROM chirps AS target
+            WHERE target.id = @chirp_id
+        )
+    RETURNING chirps.*
+)
+SELECT deleted.id, deleted.created_at, deleted.updated_at, deleted.user_id, deleted.repost_of_id,
+    original.user_id AS original_user_id
+FROM deleted
+JOIN chirps AS original ON original.id = deleted.repost_of_id;
+
 -- name: ResetChirpsTable :exec
-DELETE FROM chirps;
\ No newline at end of file
+DELETE FROM chirps;
+
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirp_listings),
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = sqlc.narg('viewer_id')::uuid) AS liked_by_me,
+    ts_rank(chirps.search_vector, query) AS rank,
+    ts_headline('english', replace(replace(replace(chirp_listings.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), query, 'StartSel=<mark>, StopSel=</mark>')::text AS snippet
+FROM chirp_listings
+JOIN chirps ON chirps.id = chirp_listings.id
+CROSS JOIN websearch_to_tsquery('english', @query::text) AS query
+WHERE chirps.search_vector @@ query
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = sqlc.narg('viewer_id')::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+ORDER BY rank DESC, chirp_listings.created_at DESC, chirp_listings.id DESC
+LIMIT @page_size OFFSET @page_offset;
\ No newline at end of file
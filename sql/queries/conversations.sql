@@ -0,0 +1,25 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, user_a_id, user_b_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetConversation :one
+SELECT * FROM conversations
+WHERE id = $1;
+
+-- name: GetConversationByParticipants :one
+SELECT * FROM conversations
+WHERE user_a_id = $1 AND user_b_id = $2;
+
+-- name: GetConversationsForUser :many
+SELECT sqlc.embed(conversations), sqlc.embed(users) FROM conversations
+JOIN users ON users.id = CASE WHEN conversations.user_a_id = @user_id THEN conversations.user_b_id ELSE conversations.user_a_id END
+WHERE (conversations.user_a_id = @user_id OR conversations.user_b_id = @user_id)
+    AND (conversations.updated_at, conversations.id) < (@before_updated_at::timestamp, @before_id::uuid)
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT @page_size;
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = $2
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- name: CreateEmailToken :exec
+INSERT INTO email_tokens (token_hash, user_id, purpose, email, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: UseEmailToken :one
+UPDATE email_tokens
+SET used_at = $3
+WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+RETURNING *;
+
+-- name: InvalidateEmailTokens :exec
+UPDATE email_tokens
+SET used_at = $3
+WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- name: FollowUser :exec
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, $3);
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT sqlc.embed(users), follows.created_at AS followed_at FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = @user_id
+    AND (follows.created_at, users.id) < (@before_followed_at::timestamp, @before_id::uuid)
+ORDER BY follows.created_at DESC, users.id DESC
+LIMIT @page_size;
+
+-- name: GetFollowing :many
+SELECT sqlc.embed(users), follows.created_at AS followed_at FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = @user_id
+    AND (follows.created_at, users.id) < (@before_followed_at::timestamp, @before_id::uuid)
+ORDER BY follows.created_at DESC, users.id DESC
+LIMIT @page_size;
+
+-- name: RemoveFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = @user_id AND followee_id = @other_user_id)
+    OR (follower_id = @other_user_id AND followee_id = @user_id);
\ No newline at end of file
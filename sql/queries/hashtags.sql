@@ -0,0 +1,34 @@
+-- name: UpsertHashtag :one
+INSERT INTO hashtags (id, created_at, tag)
+VALUES ($1, $2, $3)
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING *;
+
+-- name: AddChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: GetHashtagChirps :many
+SELECT sqlc.embed(chirp_listings),
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = sqlc.narg('viewer_id')::uuid) AS liked_by_me
+FROM chirp_listings
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirp_listings.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = @tag
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = sqlc.narg('viewer_id')::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+    AND (chirp_listings.created_at, chirp_listings.id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY chirp_listings.created_at DESC, chirp_listings.id DESC
+LIMIT @page_size;
+
+-- name: GetTrendingHashtags :many
+SELECT hashtags.tag, COUNT(*) AS usage_count FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE chirp_hashtags.created_at >= @since::timestamp
+GROUP BY hashtags.tag
+ORDER BY usage_count DESC, hashtags.tag
+LIMIT @tag_limit;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- name: LikeChirp :exec
+INSERT INTO likes (user_id, chirp_id, created_at)
+VALUES ($1, $2, $3);
+
+-- name: UnlikeChirp :execrows
+DELETE FROM likes
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetChirpLikes :many
+SELECT sqlc.embed(users), likes.created_at AS liked_at FROM likes
+JOIN users ON users.id = likes.user_id
+WHERE likes.chirp_id = @chirp_id
+    AND (likes.created_at, users.id) < (@before_liked_at::timestamp, @before_id::uuid)
+ORDER BY likes.created_at DESC, users.id DESC
+LIMIT @page_size;
\ No newline at end of file
@@ -0,0 +1,52 @@
+-- name: CreateList :one
+INSERT INTO lists (id, created_at, updated_at, owner_id, name, is_private)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetList :one
+SELECT * FROM lists
+WHERE id = $1;
+
+-- name: GetListsByOwner :many
+SELECT * FROM lists
+WHERE owner_id = $1
+ORDER BY created_at, id;
+
+-- name: UpdateList :one
+UPDATE lists
+SET name = $2, is_private = $3, updated_at = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteList :exec
+DELETE FROM lists
+WHERE id = $1;
+
+-- name: AddListMember :exec
+INSERT INTO list_members (list_id, user_id, created_at)
+VALUES ($1, $2, $3);
+
+-- name: RemoveListMember :execrows
+DELETE FROM list_members
+WHERE list_id = $1 AND user_id = $2;
+
+-- name: GetListMembers :many
+SELECT sqlc.embed(users), list_members.created_at AS added_at FROM list_members
+JOIN users ON users.id = list_members.user_id
+WHERE list_members.list_id = $1
+ORDER BY list_members.created_at, users.id;
+
+-- name: GetListChirps :many
+SELECT sqlc.embed(chirp_listings),
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = sqlc.narg('viewer_id')::uuid) AS liked_by_me
+FROM chirp_listings
+JOIN list_members ON list_members.user_id = chirp_listings.user_id
+WHERE list_members.list_id = @list_id
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = sqlc.narg('viewer_id')::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+    AND (chirp_listings.created_at, chirp_listings.id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY chirp_listings.created_at DESC, chirp_listings.id DESC
+LIMIT @page_size;
\ No newline at end of file
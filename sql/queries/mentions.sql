@@ -0,0 +1,19 @@
+-- name: AddMention :exec
+INSERT INTO mentions (chirp_id, user_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: GetMentionChirps :many
+SELECT sqlc.embed(chirp_listings),
+    EXISTS (SELECT 1 FROM likes WHERE likes.chirp_id = chirp_listings.id AND likes.user_id = sqlc.narg('viewer_id')::uuid) AS liked_by_me
+FROM chirp_listings
+JOIN mentions ON mentions.chirp_id = chirp_listings.id
+WHERE mentions.user_id = @user_id
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = sqlc.narg('viewer_id')::uuid
+            AND hidden_users.user_id IN (chirp_listings.user_id, chirp_listings.original_user_id)
+    )
+    AND (chirp_listings.created_at, chirp_listings.id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY chirp_listings.created_at DESC, chirp_listings.id DESC
+LIMIT @page_size;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- name: CreateMessage :one
+INSERT INTO messages (id, created_at, conversation_id, sender_id, body)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetConversationMessages :many
+SELECT * FROM messages
+WHERE conversation_id = @conversation_id
+    AND (created_at, id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size;
\ No newline at end of file
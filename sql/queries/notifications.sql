@@ -0,0 +1,34 @@
+-- name: CreateNotification :exec
+INSERT INTO notifications (id, created_at, user_id, type, data)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetNotifications :many
+SELECT * FROM notifications
+WHERE user_id = @user_id
+    AND (created_at, id) < (@before_created_at::timestamp, @before_id::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read_at = @read_at
+WHERE user_id = @user_id AND read_at IS NULL AND id = ANY(@ids::uuid[]);
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = $2
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: RecordLoginUserAgent :one
+INSERT INTO login_user_agents (user_id, user_agent, first_seen_at, last_seen_at)
+VALUES (@user_id, @user_agent, @seen_at, @seen_at)
+ON CONFLICT (user_id, user_agent) DO UPDATE SET last_seen_at = EXCLUDED.last_seen_at
+RETURNING first_seen_at = last_seen_at AS is_new;
+
+-- name: CountLoginUserAgents :one
+SELECT COUNT(*) FROM login_user_agents
+WHERE user_id = $1;
\ No newline at end of file
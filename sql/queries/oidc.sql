@@ -0,0 +1,21 @@
+-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (state, nonce, code_verifier, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state = $1 AND expires_at > $2
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states
+WHERE expires_at <= $1;
+
+-- name: GetUserByIdentity :one
+SELECT users.* FROM user_identities
+JOIN users ON users.id = user_identities.user_id
+WHERE user_identities.issuer = $1 AND user_identities.subject = $2;
+
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (issuer, subject, user_id, email, created_at)
+VALUES ($1, $2, $3, $4, $5);
\ No newline at end of file
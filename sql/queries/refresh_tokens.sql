@@ -1,6 +1,6 @@
 -- name: InsertRefreshTokenIntoDB :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, parent_token, user_agent, ip_address, last_used_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $2)
 RETURNING *;
 
 -- name: GetRefreshTokenByToken :one
@@ -10,5 +10,37 @@ WHERE token = $1;
 -- name: RevokeRefreshToken :one
 UPDATE refresh_tokens
 SET revoked_at = $2, updated_at = $3
-WHERE token = $1
-RETURNING *;
\ No newline at end of file
+WHERE token = $1 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens
+SET revoked_at = @rotated_at, rotated_at = @rotated_at, updated_at = @rotated_at
+WHERE token = @token AND revoked_at IS NULL;
+
+-- name: RevokeAllRefreshTokensForUser :exec
+UPDATE refresh_tokens
+SET revoked_at = $2, updated_at = $3
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: GetSessionsForUser :many
+SELECT
+    t.family_id,
+    (SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = t.family_id)::timestamp AS started_at,
+    t.user_agent,
+    t.ip_address,
+    t.last_used_at,
+    t.expires_at
+FROM refresh_tokens t
+WHERE t.user_id = $1 AND t.revoked_at IS NULL AND t.expires_at > @now::timestamp
+ORDER BY t.last_used_at DESC;
+
+-- name: TouchSession :exec
+UPDATE refresh_tokens
+SET last_used_at = @now::timestamp
+WHERE family_id = @family_id AND revoked_at IS NULL AND last_used_at < @used_before::timestamp;
+
+-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = $3, updated_at = $4
+WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL;
\ No newline at end of file
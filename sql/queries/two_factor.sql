@@ -0,0 +1,61 @@
+-- name: UpsertPendingTOTP :one
+INSERT INTO user_totp (user_id, secret, created_at, confirmed_at, last_used_step)
+VALUES ($1, $2, $3, NULL, 0)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at, last_used_step = 0
+WHERE user_totp.confirmed_at IS NULL
+RETURNING *;
+
+-- name: GetTOTPByUserID :one
+SELECT * FROM user_totp
+WHERE user_id = $1;
+
+-- name: ConfirmTOTP :execrows
+UPDATE user_totp
+SET confirmed_at = $2, last_used_step = $3
+WHERE user_id = $1 AND confirmed_at IS NULL;
+
+-- name: UseTOTPStep :execrows
+UPDATE user_totp
+SET last_used_step = $2
+WHERE user_id = $1 AND last_used_step < $2;
+
+-- name: DeleteTOTP :exec
+DELETE FROM user_totp
+WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, code_hash, created_at)
+VALUES ($1, $2, $3);
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = $3
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodesForUser :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: CreateLoginChallenge :exec
+INSERT INTO login_challenges (id, user_id, created_at, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: CreateUsedLoginChallenge :exec
+INSERT INTO login_challenges (id, user_id, created_at, expires_at, attempts, completed_at)
+VALUES (@id, @user_id, @created_at, @created_at, 1, @created_at);
+
+-- name: CountRecentLoginChallengeAttempts :one
+SELECT COALESCE(SUM(attempts), 0)::bigint FROM login_challenges
+WHERE user_id = @user_id AND created_at > @since::timestamp;
+
+-- name: AttemptLoginChallenge :execrows
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE id = @id AND user_id = @user_id
+    AND completed_at IS NULL AND expires_at > @now::timestamp AND attempts < @max_attempts::integer;
+
+-- name: CompleteLoginChallenge :execrows
+UPDATE login_challenges
+SET completed_at = $2
+WHERE id = $1 AND completed_at IS NULL;
\ No newline at end of file
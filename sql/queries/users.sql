@@ -1,6 +1,6 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetUserById :one
@@ -9,9 +9,36 @@ SELECT * FROM users WHERE id = $1;
 -- name: GetUserByEmail :one
 SELECT * FROM users WHERE email = $1;
 
+-- name: GetUserByHandle :one
+SELECT * FROM users WHERE lower(handle) = lower(@handle::text);
+
+-- name: GetUsersByHandles :many
+SELECT * FROM users
+WHERE lower(handle) = ANY(@handles::text[]);
+
 -- name: GetAllUsers :many
 SELECT * FROM users
-ORDER BY created_at;
+WHERE (created_at, id) > (@after_created_at::timestamp, @after_id::uuid)
+ORDER BY created_at, id
+LIMIT @page_size;
 
 -- name: ResetUsersTable :exec
 DELETE FROM users;
+
+-- name: UpdateUser :one
+UPDATE users
+SET email = $2, hashed_password = $3, updated_at = $4,
+    handle = $5, display_name = $6, bio = $7, avatar_url = $8,
+    email_verified_at = CASE WHEN email = $2 THEN email_verified_at END
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET hashed_password = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: MarkEmailVerified :execrows
+UPDATE users
+SET email_verified_at = $3, updated_at = $3
+WHERE id = $1 AND email = $2;
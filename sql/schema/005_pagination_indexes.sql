@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE INDEX chirps_created_at_id_idx ON chirps (created_at, id);
+CREATE INDEX chirps_user_id_created_at_id_idx ON chirps (user_id, created_at, id);
+CREATE INDEX users_created_at_id_idx ON users (created_at, id);
+
+-- +goose Down
+DROP INDEX users_created_at_id_idx;
+DROP INDEX chirps_user_id_created_at_id_idx;
+DROP INDEX chirps_created_at_id_idx;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Reposts are removed together with the chirp they repost. A quote carries
+-- its author's own words, so it outlives the chirp it quotes and only loses the
+-- reference.
+ALTER TABLE chirps
+ADD COLUMN repost_of_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+ADD COLUMN quote_of_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+ADD CONSTRAINT chirps_repost_or_quote_check CHECK (repost_of_id IS NULL OR quote_of_id IS NULL);
+
+CREATE UNIQUE INDEX chirps_user_id_repost_of_id_idx ON chirps (user_id, repost_of_id) WHERE repost_of_id IS NOT NULL;
+CREATE INDEX chirps_quote_of_id_idx ON chirps (quote_of_id) WHERE quote_of_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_quote_of_id_idx;
+DROP INDEX chirps_user_id_repost_of_id_idx;
+
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_repost_or_quote_check,
+DROP COLUMN quote_of_id,
+DROP COLUMN repost_of_id;
\ No newline at end of file
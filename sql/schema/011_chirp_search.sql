@@ -0,0 +1,13 @@
+-- +goose Up
+-- Banned words are stored masked as "****"; strip the mask so it never becomes
+-- a searchable lexeme.
+ALTER TABLE chirps
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (to_tsvector('english', replace(body, '****', ' '))) STORED;
+
+CREATE INDEX chirps_search_vector_idx ON chirps USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX chirps_search_vector_idx;
+
+ALTER TABLE chirps
+DROP COLUMN search_vector;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN handle TEXT;
+
+UPDATE users SET handle = 'user_' || substr(replace(id::text, '-', ''), 1, 10);
+
+ALTER TABLE users ALTER COLUMN handle SET NOT NULL;
+
+CREATE UNIQUE INDEX users_handle_lower_idx ON users (lower(handle));
+
+-- +goose Down
+DROP INDEX users_handle_lower_idx;
+ALTER TABLE users DROP COLUMN handle;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE refresh_tokens
+    ADD COLUMN family_id UUID,
+    ADD COLUMN parent_token TEXT REFERENCES refresh_tokens(token) ON DELETE SET NULL;
+
+-- Tokens issued before rotation each start their own family.
+UPDATE refresh_tokens SET family_id = gen_random_uuid();
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+-- +goose Down
+DROP INDEX refresh_tokens_family_id_idx;
+ALTER TABLE refresh_tokens
+    DROP COLUMN parent_token,
+    DROP COLUMN family_id;
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- +goose Up
+-- chirp_listings is a chirp as the API shows it, with its like count and the
+-- chirp it reposts or quotes.
+CREATE VIEW chirp_listings AS
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id,
+    chirps.reply_to_id, chirps.repost_of_id, chirps.quote_of_id,
+    (SELECT COUNT(*) FROM likes WHERE likes.chirp_id = chirps.id) AS like_count,
+    original.id AS original_id,
+    original.created_at AS original_created_at,
+    original.updated_at AS original_updated_at,
+    original.body AS original_body,
+    original.user_id AS original_user_id,
+    (SELECT COUNT(*) FROM likes WHERE likes.chirp_id = original.id) AS original_like_count
+FROM chirps
+LEFT JOIN chirps AS original ON original.id = COALESCE(chirps.repost_of_id, chirps.quote_of_id);
+
+-- +goose Down
+DROP VIEW chirp_listings;
\ No newline at end of file
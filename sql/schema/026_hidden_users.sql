@@ -0,0 +1,12 @@
+-- +goose Up
+-- hidden_users lists, for each viewer, the users whose content they must not
+-- see: the users they block or are blocked by, and the users they muted.
+CREATE VIEW hidden_users AS
+SELECT blocker_id AS viewer_id, blocked_id AS user_id, false AS muted FROM blocks
+UNION ALL
+SELECT blocked_id, blocker_id, false FROM blocks
+UNION ALL
+SELECT muter_id, muted_id, true FROM mutes;
+
+-- +goose Down
+DROP VIEW hidden_users;
\ No newline at end of file
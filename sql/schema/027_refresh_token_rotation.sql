@@ -0,0 +1,9 @@
+-- +goose Up
+-- rotated_at tells tokens replaced by a refresh, whose reuse means they
+-- leaked, from tokens revoked on purpose, by logging out for instance.
+ALTER TABLE refresh_tokens
+    ADD COLUMN rotated_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE refresh_tokens
+    DROP COLUMN rotated_at;
\ No newline at end of file
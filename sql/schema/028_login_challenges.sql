@@ -0,0 +1,19 @@
+-- +goose Up
+-- A login challenge is issued when the password step of a login passes for a
+-- user with two-factor authentication. Counting the codes tried against it
+-- bounds how many guesses a stolen password buys. Codes tried outside of a
+-- login, to turn two-factor authentication off, are recorded as challenges
+-- used up by their single attempt so that they count toward the same limit.
+CREATE TABLE login_challenges (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX login_challenges_user_id_created_at_idx ON login_challenges (user_id, created_at);
+
+-- +goose Down
+DROP TABLE login_challenges;
\ No newline at end of file
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/broker"
+)
+
+const eventChirpCreated = "chirp_created"
+const eventChirpDeleted = "chirp_deleted"
+
+const streamHistorySize = 1000
+const streamBufferSize = 64
+const streamHeartbeatInterval = 15 * time.Second
+
+// hiddenUsersMaxAge bounds how long an open stream keeps showing a user its
+// viewer has just blocked or muted.
+const hiddenUsersMaxAge = 30 * time.Second
+
+// streamViewer is who an open stream or websocket is for. Their blocks and
+// mutes are loaded when first needed and reloaded once older than
+// hiddenUsersMaxAge, rather than queried for every event.
+type streamViewer struct {
+	ID				uuid.NullUUID
+	hiddenUsers		map[uuid.UUID]struct{}
+	hiddenLoadedAt	time.Time
+}
+
+// handlerStream pushes newly created chirps as Server-Sent Events. Clients can
+// limit the stream to some authors with one or more author_id parameters and
+// resume after a disconnect through the Last-Event-ID header. Authenticated
+// viewers do not get the chirps listings would hide from them.
+func (cfg *apiConfig) handlerStream(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.optionalUserID(r, scopeChirpsRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	viewer := &streamViewer{ID: viewerID}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	authorIDs, err := parseAuthorIDs(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid author ID")
+		return
+	}
+
+	var lastEventID uint64
+	if rawLastEventID := r.Header.Get("Last-Event-ID"); rawLastEventID != "" {
+		lastEventID, err = strconv.ParseUint(rawLastEventID, 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+	}
+
+	sub, missed := cfg.events.Subscribe(lastEventID, createdChirpFilter(authorIDs))
+	defer sub.Unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range missed {
+		if cfg.hidesEvent(r.Context(), viewer, event) {
+			continue
+		}
+		if err := writeServerSentEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// The broker dropped us for falling behind; the client
+				// reconnects and resumes from the last event it got.
+				return
+			}
+			if cfg.hidesEvent(r.Context(), viewer, event) {
+				continue
+			}
+			if err := writeServerSentEvent(w, event); err != nil {
+				log.Printf("error writing event %d to stream: %v", event.ID, err)
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// hidesEvent reports whether event involves a user viewer blocks, is blocked
+// by or muted. Events are hidden when that cannot be checked.
+func (cfg *apiConfig) hidesEvent(ctx context.Context, viewer *streamViewer, event broker.Event) bool {
+	if !viewer.ID.Valid {
+		return false
+	}
+
+	if viewer.hiddenUsers == nil || time.Since(viewer.hiddenLoadedAt) >= hiddenUsersMaxAge {
+		userIDs, err := cfg.db.GetHiddenUserIDs(ctx, viewer.ID.UUID)
+		if err != nil {
+			log.Printf("error retrieving users hidden from %s: %v", viewer.ID.UUID, err)
+			return true
+		}
+		viewer.hiddenUsers = make(map[uuid.UUID]struct{}, len(userIDs))
+		for _, userID := range userIDs {
+			viewer.hiddenUsers[userID] = struct{}{}
+		}
+		viewer.hiddenLoadedAt = time.Now()
+	}
+
+	for _, userID := range event.UserIDs {
+		if _, ok := viewer.hiddenUsers[userID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// publishChirp announces a newly created chirp to stream and websocket subscribers.
+func (cfg *apiConfig) publishChirp(chirp Chirp) {
+	event := broker.Event{
+		Type:     eventChirpCreated,
+		AuthorID: chirp.UserID,
+		ChirpIDs: relatedChirpIDs(chirp.ID, chirp.ReplyToID, chirp.RepostOfID, chirp.QuoteOfID),
+		UserIDs:  contentUserIDs(chirp),
+	}
+	if _, err := cfg.events.Publish(event, chirp); err != nil {
+		log.Printf("error publishing chirp %s: %v", chirp.ID, err)
+	}
+}
+
+func (cfg *apiConfig) publishChirpDeleted(chirp Chirp) {
+	type chirpDeletedData struct {
+		ID		uuid.UUID	`json:"id"`
+		UserID	uuid.UUID	`json:"user_id"`
+	}
+
+	event := broker.Event{
+		Type:     eventChirpDeleted,
+		AuthorID: chirp.UserID,
+		ChirpIDs: relatedChirpIDs(chirp.ID, chirp.ReplyToID, chirp.RepostOfID, chirp.QuoteOfID),
+		UserIDs:  contentUserIDs(chirp),
+	}
+	if _, err := cfg.events.Publish(event, chirpDeletedData{ID: chirp.ID, UserID: chirp.UserID}); err != nil {
+		log.Printf("error publishing deletion of chirp %s: %v", chirp.ID, err)
+	}
+}
+
+// contentUserIDs lists the author of a chirp and of the chirp it reposts or
+// quotes, the users whose blocks and mutes hide it from listings.
+func contentUserIDs(chirp Chirp) []uuid.UUID {
+	userIDs := []uuid.UUID{chirp.UserID}
+	if chirp.Original != nil {
+		userIDs = append(userIDs, chirp.Original.UserID)
+	}
+	return userIDs
+}
+
+// relatedChirpIDs lists a chirp and the chirps it replies to, reposts or
+// quotes, so that followers of any of them get its events.
+func relatedChirpIDs(id uuid.UUID, references ...uuid.NullUUID) []uuid.UUID {
+	ids := []uuid.UUID{id}
+	for _, reference := range references {
+		if reference.Valid {
+			ids = append(ids, reference.UUID)
+		}
+	}
+	return ids
+}
+
+func writeServerSentEvent(w http.ResponseWriter, event broker.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
+
+// parseAuthorIDs reads author_id query parameters, each of which may hold a
+// comma separated list.
+func parseAuthorIDs(r *http.Request) ([]uuid.UUID, error) {
+	authorIDs := []uuid.UUID{}
+	for _, rawValue := range r.URL.Query()["author_id"] {
+		for _, rawID := range strings.Split(rawValue, ",") {
+			authorID, err := uuid.Parse(strings.TrimSpace(rawID))
+			if err != nil {
+				return nil, err
+			}
+			authorIDs = append(authorIDs, authorID)
+		}
+	}
+	return authorIDs, nil
+}
+
+// createdChirpFilter accepts chirp_created events, limited to authorIDs unless
+// it is empty.
+func createdChirpFilter(authorIDs []uuid.UUID) broker.Filter {
+	wanted := make(map[uuid.UUID]struct{}, len(authorIDs))
+	for _, authorID := range authorIDs {
+		wanted[authorID] = struct{}{}
+	}
+	return func(event broker.Event) bool {
+		if event.Type != eventChirpCreated {
+			return false
+		}
+		if len(wanted) == 0 {
+			return true
+		}
+		_, ok := wanted[event.AuthorID]
+		return ok
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/auth"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+const totpIssuer = "Chirpy"
+const challengeTokenDuration = 5 * time.Minute
+
+// maxChallengeAttempts is how many codes can be tried against one login
+// challenge. maxSecondFactorAttempts bounds them across all the challenges of
+// a user within secondFactorAttemptWindow, since anyone knowing the password
+// can get new challenges.
+const maxChallengeAttempts = 5
+const maxSecondFactorAttempts = 10
+const secondFactorAttemptWindow = 15 * time.Minute
+const recoveryCodeCount = 10
+
+// handlerEnrollTOTP starts two-factor enrollment with a new secret. Nothing
+// changes at login until the secret is confirmed with a first code.
+func (cfg *apiConfig) handlerEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		log.Printf("error retrieving user %s while enrolling totp: %v", userID, err)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error generating secret")
+		log.Printf("error generating totp secret for user %s: %v", userID, err)
+		return
+	}
+
+	// Enrolling again before confirming replaces the pending secret.
+	_, err = cfg.db.UpsertPendingTOTP(r.Context(), database.UpsertPendingTOTPParams{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusConflict, "two-factor authentication is already enabled")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error saving secret")
+		log.Printf("error saving totp secret for user %s: %v", userID, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(secret, totpIssuer, user.Email),
+	})
+}
+
+// handlerConfirmTOTP enables two-factor authentication once the user proves
+// their authenticator works, and returns the recovery codes. This is the only
+// time the codes are shown.
+func (cfg *apiConfig) handlerConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code	string	`json:"code"`
+	}
+
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	totp, err := cfg.db.GetTOTPByUserID(r.Context(), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusBadRequest, "two-factor enrollment has not been started")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving two-factor settings")
+		log.Printf("error retrieving totp of user %s: %v", userID, err)
+		return
+	}
+	if totp.ConfirmedAt.Valid {
+		respondWithError(w, http.StatusConflict, "two-factor authentication is already enabled")
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(totp.Secret, params.Code, time.Now().UTC())
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "invalid code")
+		return
+	}
+
+	recoveryCodes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error generating recovery codes")
+		log.Printf("error generating recovery codes for user %s: %v", userID, err)
+		return
+	}
+
+	err = cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		confirmed, err := q.ConfirmTOTP(r.Context(), database.ConfirmTOTPParams{
+			UserID: userID,
+			ConfirmedAt: sql.NullTime{
+				Time:  time.Now().UTC(),
+				Valid: true,
+			},
+			LastUsedStep: step,
+		})
+		if err != nil {
+			return err
+		}
+		if confirmed == 0 {
+			return sql.ErrNoRows
+		}
+		err = q.DeleteRecoveryCodesForUser(r.Context(), userID)
+		if err != nil {
+			return err
+		}
+		for _, code := range recoveryCodes {
+			err := q.CreateRecoveryCode(r.Context(), database.CreateRecoveryCodeParams{
+				UserID:    userID,
+				CodeHash:  auth.HashRecoveryCode(code),
+				CreatedAt: time.Now().UTC(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Confirmed by a concurrent request.
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusConflict, "two-factor authentication is already enabled")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error enabling two-factor authentication")
+		log.Printf("error confirming totp of user %s: %v", userID, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{RecoveryCodes: recoveryCodes})
+}
+
+// handlerDisableTOTP turns two-factor authentication off. It takes a current
+// code or a recovery code, so a stolen access token is not enough. Codes tried
+// here count toward the same per-user limit as the ones tried at login.
+func (cfg *apiConfig) handlerDisableTOTP(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code	string	`json:"code"`
+	}
+
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if !cfg.checkSecondFactorAttempts(w, r, userID) {
+		return
+	}
+	// Like at login, the attempt is counted before the code is checked.
+	err = cfg.db.CreateUsedLoginChallenge(r.Context(), database.CreateUsedLoginChallengeParams{
+		ID:        uuid.New(),
+		UserID:    userID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error checking code")
+		log.Printf("error counting second factor attempt of user %s: %v", userID, err)
+		return
+	}
+
+	ok, err := cfg.verifySecondFactor(r.Context(), userID, params.Code)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error checking code")
+		log.Printf("error checking second factor of user %s: %v", userID, err)
+		return
+	}
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "invalid code")
+		return
+	}
+
+	err = cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		err := q.DeleteTOTP(r.Context(), userID)
+		if err != nil {
+			return err
+		}
+		return q.DeleteRecoveryCodesForUser(r.Context(), userID)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error disabling two-factor authentication")
+		log.Printf("error deleting totp of user %s: %v", userID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerLoginSecondFactor completes a login started by handlerLoginByPassword,
+// exchanging its challenge token and a code for the usual tokens. A challenge
+// is used up by a successful login or after maxChallengeAttempts codes.
+func (cfg *apiConfig) handlerLoginSecondFactor(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ChallengeToken	string	`json:"challenge_token"`
+		Code			string	`json:"code"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		log.Printf("error decoding request payload while logging in with second factor: %v", err)
+		return
+	}
+
+	userID, challengeID, err := auth.ValidateChallengeJWT(params.ChallengeToken, cfg.keyring)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid challenge token")
+		return
+	}
+
+	if !cfg.checkSecondFactorAttempts(w, r, userID) {
+		return
+	}
+
+	// The attempt is counted before the code is checked, so concurrent
+	// guesses cannot get past the limit.
+	attempted, err := cfg.db.AttemptLoginChallenge(r.Context(), database.AttemptLoginChallengeParams{
+		ID:          challengeID,
+		UserID:      userID,
+		Now:         time.Now().UTC(),
+		MaxAttempts: maxChallengeAttempts,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error checking code")
+		log.Printf("error counting attempt on login challenge %s: %v", challengeID, err)
+		return
+	}
+	if attempted == 0 {
+		respondWithError(w, http.StatusUnauthorized, "challenge expired or used up, log in again")
+		return
+	}
+
+	ok, err := cfg.verifySecondFactor(r.Context(), userID, params.Code)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error checking code")
+		log.Printf("error checking second factor of user %s: %v", userID, err)
+		return
+	}
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "invalid code")
+		return
+	}
+
+	completed, err := cfg.db.CompleteLoginChallenge(r.Context(), database.CompleteLoginChallengeParams{
+		ID:          challengeID,
+		CompletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error completing login")
+		log.Printf("error completing login challenge %s: %v", challengeID, err)
+		return
+	}
+	if completed == 0 {
+		respondWithError(w, http.StatusUnauthorized, "challenge expired or used up, log in again")
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusUnauthorized, "invalid challenge token")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		log.Printf("error retrieving user %s while logging in with second factor: %v", userID, err)
+		return
+	}
+
+	cfg.issueSession(w, r, user)
+}
+
+// checkSecondFactorAttempts reports whether userID may try another code,
+// answering the request when they may not.
+func (cfg *apiConfig) checkSecondFactorAttempts(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	recentAttempts, err := cfg.db.CountRecentLoginChallengeAttempts(r.Context(), database.CountRecentLoginChallengeAttemptsParams{
+		UserID: userID,
+		Since:  time.Now().UTC().Add(-secondFactorAttemptWindow),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error checking code")
+		log.Printf("error counting second factor attempts of user %s: %v", userID, err)
+		return false
+	}
+	if recentAttempts >= maxSecondFactorAttempts {
+		respondWithError(w, http.StatusTooManyRequests, "too many attempts, try again later")
+		return false
+	}
+	return true
+}
+
+func (cfg *apiConfig) respondWithLoginChallenge(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	challengeID := uuid.New()
+	err := cfg.db.CreateLoginChallenge(r.Context(), database.CreateLoginChallengeParams{
+		ID:        challengeID,
+		UserID:    userID,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: time.Now().UTC().Add(challengeTokenDuration),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error while generating token")
+		log.Printf("error saving login challenge for user %s: %v", userID, err)
+		return
+	}
+
+	challengeToken, err := auth.MakeChallengeJWT(userID, challengeID, cfg.keyring, challengeTokenDuration)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error while generating token")
+		log.Printf("error generating challenge token for user %s: %v", userID, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, struct {
+		TwoFactorRequired bool   `json:"two_factor_required"`
+		ChallengeToken    string `json:"challenge_token"`
+	}{TwoFactorRequired: true, ChallengeToken: challengeToken})
+}
+
+// verifySecondFactor reports whether code is a valid TOTP code or unused
+// recovery code of a user with two-factor authentication enabled. Either is
+// consumed, so the same code never works twice.
+func (cfg *apiConfig) verifySecondFactor(ctx context.Context, userID uuid.UUID, code string) (bool, error) {
+	totp, err := cfg.db.GetTOTPByUserID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if !totp.ConfirmedAt.Valid {
+		return false, nil
+	}
+
+	if step, ok := auth.ValidateTOTP(totp.Secret, code, time.Now().UTC()); ok {
+		used, err := cfg.db.UseTOTPStep(ctx, database.UseTOTPStepParams{
+			UserID:       userID,
+			LastUsedStep: step,
+		})
+		return used == 1, err
+	}
+
+	used, err := cfg.db.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: auth.HashRecoveryCode(code),
+		UsedAt: sql.NullTime{
+			Time:  time.Now().UTC(),
+			Valid: true,
+		},
+	})
+	return used == 1, err
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/auth"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+// enableTestTOTP turns two-factor authentication on for userID with a single
+// recovery code, which it returns.
+func enableTestTOTP(t *testing.T, cfg *apiConfig, userID uuid.UUID) string {
+	t.Helper()
+
+	ctx := context.Background()
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("generating secret: %v", err)
+	}
+	if _, err := cfg.db.UpsertPendingTOTP(ctx, database.UpsertPendingTOTPParams{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("UpsertPendingTOTP: %v", err)
+	}
+	if _, err := cfg.db.ConfirmTOTP(ctx, database.ConfirmTOTPParams{
+		UserID:      userID,
+		ConfirmedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	}); err != nil {
+		t.Fatalf("ConfirmTOTP: %v", err)
+	}
+
+	recoveryCode := "abcde-fghjk"
+	if err := cfg.db.CreateRecoveryCode(ctx, database.CreateRecoveryCodeParams{
+		UserID:    userID,
+		CodeHash:  auth.HashRecoveryCode(recoveryCode),
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("CreateRecoveryCode: %v", err)
+	}
+	return recoveryCode
+}
+
+func disableTOTP(t *testing.T, cfg *apiConfig, userID uuid.UUID, code string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/2fa/disable", strings.NewReader(`{"code": "`+code+`"}`))
+	r.Header.Set("Authorization", accessToken(t, cfg, userID))
+	w := httptest.NewRecorder()
+	cfg.handlerDisableTOTP(w, r)
+	return w
+}
+
+func TestDisableTOTPLimitsAttempts(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com", "password")
+	recoveryCode := enableTestTOTP(t, cfg, user.ID)
+
+	for i := 0; i < maxSecondFactorAttempts; i++ {
+		if w := disableTOTP(t, cfg, user.ID, "000000"); w.Code != http.StatusBadRequest {
+			t.Fatalf("attempt %d: status = %d, want %d", i+1, w.Code, http.StatusBadRequest)
+		}
+	}
+
+	// Even the right code is refused once the limit is reached, and so are
+	// logins.
+	if w := disableTOTP(t, cfg, user.ID, recoveryCode); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if _, err := cfg.db.GetTOTPByUserID(context.Background(), user.ID); err != nil {
+		t.Errorf("two-factor authentication was turned off: %v", err)
+	}
+	w := httptest.NewRecorder()
+	cfg.respondWithLoginChallenge(w, httptest.NewRequest(http.MethodPost, "/api/login", nil), user.ID)
+	challenge := struct {
+		ChallengeToken string `json:"challenge_token"`
+	}{}
+	if err := json.NewDecoder(w.Body).Decode(&challenge); err != nil {
+		t.Fatalf("decoding challenge: %v", err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/api/login/2fa", strings.NewReader(`{"challenge_token": "`+challenge.ChallengeToken+`", "code": "`+recoveryCode+`"}`))
+	w = httptest.NewRecorder()
+	cfg.handlerLoginSecondFactor(w, r)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("login status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com", "password")
+	recoveryCode := enableTestTOTP(t, cfg, user.ID)
+
+	if w := disableTOTP(t, cfg, user.ID, recoveryCode); w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body)
+	}
+	if _, err := cfg.db.GetTOTPByUserID(context.Background(), user.ID); err != sql.ErrNoRows {
+		t.Errorf("GetTOTPByUserID = %v, want %v", err, sql.ErrNoRows)
+	}
+}
@@ -3,10 +3,15 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/leonardomlouzas/GOose/internal/auth"
@@ -16,9 +21,28 @@ import (
 const accessTokenDuration = time.Hour
 const refreshTokenDuration = time.Hour * 24 * 60 // 60 days
 
+// refreshTokenReuseGrace is how long after a rotation the replaced token is
+// only refused rather than taken as stolen, so that two clients sharing a
+// token and refreshing at once do not sign the user out. A token replayed in
+// that window gets nothing either: only the reuse goes unpunished.
+const refreshTokenReuseGrace = 10 * time.Second
+
+var errRefreshTokenRevoked = errors.New("refresh token revoked")
+
+const maxDisplayNameLength = 50
+const maxBioLength = 160
+const maxAvatarURLLength = 2048
+
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,15}$`)
+
 type User struct {
 	ID        		uuid.UUID	`json:"id"`
-	Email     		string	    `json:"email"`
+	Email     		string	    `json:"email,omitempty"`
+	EmailVerified	*bool		`json:"email_verified,omitempty"`
+	Handle			string		`json:"handle"`
+	DisplayName		string		`json:"display_name"`
+	Bio				string		`json:"bio"`
+	AvatarURL		string		`json:"avatar_url"`
 	CreatedAt 		time.Time	`json:"created_at"`
 	UpdatedAt 		time.Time	`json:"updated_at"`
 	Token			string		`json:"token"`
@@ -29,6 +53,7 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 	type parameters struct {
 		Email		string	`json:"email"`
 		Password	string	`json:"password"`
+		Handle		string	`json:"handle"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -49,6 +74,12 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	handle := strings.TrimSpace(params.Handle)
+	if !handlePattern.MatchString(handle) {
+		respondWithError(w, http.StatusBadRequest, "handle must be 3 to 15 letters, digits or underscores")
+		return
+	}
+
 	hashedPassword, err := auth.HashPassword(password)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "invalid password")
@@ -62,23 +93,45 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		CreatedAt: 		time.Now().UTC(),
 		UpdatedAt: 		time.Now().UTC(),
 		HashedPassword:	hashedPassword,
+		Handle:			handle,
 	})
 	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "email or handle already in use")
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "error creating user")
 		log.Printf("error inserting user into db while creating user: %s", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, User{
-		ID:        user.ID,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	})
+	// The account works without verification, so a failure here only means
+	// the user has to ask for another email.
+	if err := cfg.sendVerificationEmail(r.Context(), user); err != nil {
+		log.Printf("error sending verification email to new user %s: %v", user.ID, err)
+	}
+
+	respondWithJSON(w, http.StatusCreated, databaseUserToOwnUser(user))
 }
 
 func (cfg *apiConfig) handlerGetAllUsers(w http.ResponseWriter, r *http.Request) {
-	dbUsers, err := cfg.db.GetAllUsers(r.Context())
+	viewerID, err := cfg.optionalUserID(r, scopeUsersRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dbUsers, err := cfg.db.GetAllUsers(r.Context(), database.GetAllUsersParams{
+		AfterCreatedAt: page.after().Time,
+		AfterID:        page.after().ID,
+		PageSize:       page.fetchSize(),
+	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "error retrieving users")
 		log.Printf("error retrieving users table: %s", err)
@@ -87,17 +140,51 @@ func (cfg *apiConfig) handlerGetAllUsers(w http.ResponseWriter, r *http.Request)
 
 	users := make([]User, len(dbUsers))
 	for i, dbUser := range dbUsers {
-		users[i] = User{
-			ID:        dbUser.ID,
-			Email:     dbUser.Email,
-			CreatedAt: dbUser.CreatedAt,
-			UpdatedAt: dbUser.UpdatedAt,
-		}
+		users[i] = userForViewer(dbUser, viewerID)
 	}
-	respondWithJSON(w, http.StatusOK, users)
+	respondWithJSON(w, http.StatusOK, paginate(w, r, page, users, userCursor))
+}
+
+// databaseUserToUser returns the public profile of user, without the email.
+func databaseUserToUser(user database.User) User {
+	return User{
+		ID:          user.ID,
+		Handle:      user.Handle,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarURL:   user.AvatarUrl,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+}
+
+// databaseUserToOwnUser also includes the fields only the account owner may see.
+func databaseUserToOwnUser(user database.User) User {
+	result := databaseUserToUser(user)
+	result.Email = user.Email
+	emailVerified := user.EmailVerifiedAt.Valid
+	result.EmailVerified = &emailVerified
+	return result
+}
+
+func userForViewer(user database.User, viewerID uuid.NullUUID) User {
+	if viewerID.Valid && viewerID.UUID == user.ID {
+		return databaseUserToOwnUser(user)
+	}
+	return databaseUserToUser(user)
+}
+
+func userCursor(user User) pageCursor {
+	return pageCursor{Time: user.CreatedAt, ID: user.ID}
 }
 
 func (cfg *apiConfig) handlerGetUserByID(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.optionalUserID(r, scopeUsersRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
 	userID := r.PathValue("id")
 	uid, err := uuid.Parse(userID)
 	if err != nil {
@@ -118,12 +205,238 @@ func (cfg *apiConfig) handlerGetUserByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, User{
-		ID:        user.ID,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+	blocked, err := cfg.isBlockedBetween(r.Context(), viewerID, user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		log.Printf("error checking blocks between %s and %s: %v", viewerID.UUID, user.ID, err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, userForViewer(user, viewerID))
+}
+
+func (cfg *apiConfig) handlerGetUserByHandle(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.optionalUserID(r, scopeUsersRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	handle := r.PathValue("handle")
+	if !handlePattern.MatchString(handle) {
+		respondWithError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	user, err := cfg.db.GetUserByHandle(r.Context(), handle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		log.Printf("error retrieving user by handle: %s. Error: %v", handle, err)
+		return
+	}
+
+	blocked, err := cfg.isBlockedBetween(r.Context(), viewerID, user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		log.Printf("error checking blocks between %s and %s: %v", viewerID.UUID, user.ID, err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, userForViewer(user, viewerID))
+}
+
+func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email		string	`json:"email"`
+		Password	string	`json:"password"`
+		Handle		*string	`json:"handle"`
+		DisplayName	*string	`json:"display_name"`
+		Bio			*string	`json:"bio"`
+		AvatarURL	*string	`json:"avatar_url"`
+	}
+
+	userID, err := cfg.authenticatedUserID(r)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		log.Printf("error decoding request payload while updating user: %v", err)
+		return
+	}
+
+	email := strings.TrimSpace(params.Email)
+	password := strings.TrimSpace(params.Password)
+
+	profileChanged := params.Handle != nil || params.DisplayName != nil || params.Bio != nil || params.AvatarURL != nil
+	if email == "" && password == "" && !profileChanged {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		log.Print("error while updating user. No field to update provided")
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user")
+		log.Printf("error retrieving user by id while updating user. Error: %s", err)
+		return
+	}
+
+	if email == "" {
+		email = user.Email
+	}
+
+	handle := user.Handle
+	if params.Handle != nil {
+		handle = strings.TrimSpace(*params.Handle)
+		if !handlePattern.MatchString(handle) {
+			respondWithError(w, http.StatusBadRequest, "handle must be 3 to 15 letters, digits or underscores")
+			return
+		}
+	}
+
+	displayName := user.DisplayName
+	if params.DisplayName != nil {
+		displayName = strings.TrimSpace(*params.DisplayName)
+	}
+	bio := user.Bio
+	if params.Bio != nil {
+		bio = strings.TrimSpace(*params.Bio)
+	}
+	avatarURL := user.AvatarUrl
+	if params.AvatarURL != nil {
+		avatarURL = strings.TrimSpace(*params.AvatarURL)
+	}
+
+	if err := validateProfile(displayName, bio, avatarURL); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hashedPassword := user.HashedPassword
+	passwordChanged := false
+	if password != "" && auth.CheckPasswordHash(password, user.HashedPassword) != nil {
+		hashedPassword, err = auth.HashPassword(password)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid password")
+			log.Printf("error while hashing password. Error: %s", err)
+			return
+		}
+		passwordChanged = true
+	}
+
+	var updatedUser database.User
+	err = cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		updatedUser, err = q.UpdateUser(r.Context(), database.UpdateUserParams{
+			ID:             user.ID,
+			Email:          email,
+			HashedPassword: hashedPassword,
+			UpdatedAt:      time.Now().UTC(),
+			Handle:         handle,
+			DisplayName:    displayName,
+			Bio:            bio,
+			AvatarUrl:      avatarURL,
+		})
+		if err != nil {
+			return err
+		}
+		if updatedUser.Email != user.Email {
+			// Reset tokens sent to the old address must not outlive it.
+			err = q.InvalidateEmailTokens(r.Context(), database.InvalidateEmailTokensParams{
+				UserID:  user.ID,
+				Purpose: emailTokenPasswordReset,
+				UsedAt: sql.NullTime{
+					Time:  time.Now().UTC(),
+					Valid: true,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if !passwordChanged {
+			return nil
+		}
+
+		// Sessions and API keys created with the old password must not
+		// outlive it.
+		err = q.RevokeAllRefreshTokensForUser(r.Context(), database.RevokeAllRefreshTokensForUserParams{
+			UserID:    user.ID,
+			RevokedAt: sql.NullTime{
+				Time:  time.Now().UTC(),
+				Valid: true,
+			},
+			UpdatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		return q.RevokeAllAPIKeysForUser(r.Context(), database.RevokeAllAPIKeysForUserParams{
+			UserID: user.ID,
+			RevokedAt: sql.NullTime{
+				Time:  time.Now().UTC(),
+				Valid: true,
+			},
+		})
 	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "email or handle already in use")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error updating user")
+		log.Printf("error updating user %s. Error: %s", user.ID, err)
+		return
+	}
+
+	if updatedUser.Email != user.Email {
+		if err := cfg.sendVerificationEmail(r.Context(), updatedUser); err != nil {
+			log.Printf("error sending verification email after email change for user %s: %v", user.ID, err)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseUserToOwnUser(updatedUser))
+}
+
+func validateProfile(displayName, bio, avatarURL string) error {
+	if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+		return fmt.Errorf("display name must be at most %d characters", maxDisplayNameLength)
+	}
+	if utf8.RuneCountInString(bio) > maxBioLength {
+		return fmt.Errorf("bio must be at most %d characters", maxBioLength)
+	}
+	if avatarURL == "" {
+		return nil
+	}
+	if len(avatarURL) > maxAvatarURLLength {
+		return fmt.Errorf("avatar URL must be at most %d characters", maxAvatarURLLength)
+	}
+	parsed, err := url.Parse(avatarURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("avatar URL must be an absolute http or https URL")
+	}
+	return nil
 }
 
 func (cfg *apiConfig) handlerLoginByPassword(w http.ResponseWriter, r *http.Request) {
@@ -167,7 +480,31 @@ func (cfg *apiConfig) handlerLoginByPassword(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	token, err := auth.MakeJWT(user.ID, cfg.jwt_secret, accessTokenDuration)
+	cfg.completeLogin(w, r, user)
+}
+
+// completeLogin finishes a login once user is identified: users with
+// two-factor authentication get a challenge, everyone else a session.
+func (cfg *apiConfig) completeLogin(w http.ResponseWriter, r *http.Request, user database.User) {
+	totp, err := cfg.db.GetTOTPByUserID(r.Context(), user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving two-factor settings")
+		log.Printf("error retrieving totp of user %s while logging in. Error: %s", user.ID, err)
+		return
+	}
+	if err == nil && totp.ConfirmedAt.Valid {
+		cfg.respondWithLoginChallenge(w, r, user.ID)
+		return
+	}
+
+	cfg.issueSession(w, r, user)
+}
+
+// issueSession logs user in: it opens a new session with a fresh refresh
+// token family and responds with the user and their tokens.
+func (cfg *apiConfig) issueSession(w http.ResponseWriter, r *http.Request, user database.User) {
+	familyID := uuid.New()
+	token, err := auth.MakeJWT(user.ID, familyID, cfg.keyring, accessTokenDuration)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "error while generating token")
 		log.Printf("error generating token while login. Error: %s", err)
@@ -181,13 +518,23 @@ func (cfg *apiConfig) handlerLoginByPassword(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	refreshTokenDB, err := cfg.db.InsertRefreshTokenIntoDB(r.Context(), database.InsertRefreshTokenIntoDBParams{
-		Token:     refreshToken,
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		UserID:    user.ID,
-		ExpiresAt: time.Now().UTC().Add(refreshTokenDuration),
-		RevokedAt: sql.NullTime{},
+	var refreshTokenDB database.RefreshToken
+	err = cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		refreshTokenDB, err = q.InsertRefreshTokenIntoDB(r.Context(), database.InsertRefreshTokenIntoDBParams{
+			Token:     refreshToken,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			UserID:    user.ID,
+			ExpiresAt: time.Now().UTC().Add(refreshTokenDuration),
+			RevokedAt: sql.NullTime{},
+			FamilyID:  familyID,
+			UserAgent: clientUserAgent(r),
+			IpAddress: clientIP(r),
+		})
+		if err != nil {
+			return err
+		}
+		return notifyIfNewUserAgent(r.Context(), q, user.ID, r)
 	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "error while saving refresh token")
@@ -195,16 +542,16 @@ func (cfg *apiConfig) handlerLoginByPassword(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, User{
-		ID:        user.ID,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Token:     token,
-		RefreshToken: refreshTokenDB.Token,
-	})
+	response := databaseUserToOwnUser(user)
+	response.Token = token
+	response.RefreshToken = refreshTokenDB.Token
+	respondWithJSON(w, http.StatusOK, response)
 }
 
+// handlerRefreshToken exchanges a refresh token for a new access token and a
+// new refresh token, rotating out the one presented. A token is only valid
+// until it is rotated or revoked, so presenting it again means it leaked: every
+// refresh token of its user is then revoked.
 func (cfg *apiConfig) handlerRefreshToken(w http.ResponseWriter, r *http.Request) {
 	refreshTokenString, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -219,6 +566,11 @@ func (cfg *apiConfig) handlerRefreshToken(w http.ResponseWriter, r *http.Request
 	}
 
 	if refreshTokenDB.RevokedAt.Valid {
+		if refreshTokenReused(refreshTokenDB, time.Now().UTC()) {
+			cfg.revokeReusedRefreshToken(w, r, refreshTokenDB)
+			return
+		}
+		log.Printf("refresh token of user %s, family %s, presented again within the grace period", refreshTokenDB.UserID, refreshTokenDB.FamilyID)
 		respondWithError(w, http.StatusUnauthorized, "refresh token has been revoked")
 		return
 	}
@@ -228,14 +580,104 @@ func (cfg *apiConfig) handlerRefreshToken(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	token, err := auth.MakeJWT(refreshTokenDB.UserID, cfg.jwt_secret, accessTokenDuration)
+	newRefreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error while generating refresh token")
+		log.Printf("error generating refresh token while refreshing. Error: %s", err)
+		return
+	}
+
+	err = cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		rotated, err := q.RotateRefreshToken(r.Context(), database.RotateRefreshTokenParams{
+			Token: refreshTokenDB.Token,
+			RotatedAt: sql.NullTime{
+				Time:  time.Now().UTC(),
+				Valid: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if rotated == 0 {
+			return errRefreshTokenRevoked
+		}
+		_, err = q.InsertRefreshTokenIntoDB(r.Context(), database.InsertRefreshTokenIntoDBParams{
+			Token:       newRefreshToken,
+			CreatedAt:   time.Now().UTC(),
+			UpdatedAt:   time.Now().UTC(),
+			UserID:      refreshTokenDB.UserID,
+			ExpiresAt:   time.Now().UTC().Add(refreshTokenDuration),
+			RevokedAt:   sql.NullTime{},
+			FamilyID:    refreshTokenDB.FamilyID,
+			ParentToken: sql.NullString{String: refreshTokenDB.Token, Valid: true},
+			UserAgent:   clientUserAgent(r),
+			IpAddress:   clientIP(r),
+		})
+		return err
+	})
+	if err != nil {
+		// A concurrent request rotated or revoked the same token first.
+		if err == errRefreshTokenRevoked {
+			respondWithError(w, http.StatusUnauthorized, "refresh token has been revoked")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "error while rotating refresh token")
+		log.Printf("error rotating refresh token of user %s: %v", refreshTokenDB.UserID, err)
+		return
+	}
+
+	token, err := auth.MakeJWT(refreshTokenDB.UserID, refreshTokenDB.FamilyID, cfg.keyring, accessTokenDuration)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "error while generating token")
 		log.Printf("error generating new access token from refresh token. Error: %s", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, struct{Token string `json:"token"`}{Token: token})
+	respondWithJSON(w, http.StatusOK, struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}{Token: token, RefreshToken: newRefreshToken})
+}
+
+// refreshTokenReused reports whether presenting the revoked token at now counts
+// as reuse. Whether it was rotated or revoked through /api/revoke does not
+// matter, except that a token rotated less than refreshTokenReuseGrace ago is
+// let off.
+func refreshTokenReused(token database.RefreshToken, now time.Time) bool {
+	if !token.RevokedAt.Valid {
+		return false
+	}
+	return !token.RotatedAt.Valid || now.Sub(token.RotatedAt.Time) > refreshTokenReuseGrace
+}
+
+// revokeReusedRefreshToken handles a refresh token presented after it was
+// rotated or revoked by revoking every refresh token of its user, signing them
+// out of all their sessions, and telling them. Access tokens already issued
+// stay valid until they expire.
+func (cfg *apiConfig) revokeReusedRefreshToken(w http.ResponseWriter, r *http.Request, reused database.RefreshToken) {
+	now := time.Now().UTC()
+	err := cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		err := q.RevokeAllRefreshTokensForUser(r.Context(), database.RevokeAllRefreshTokensForUserParams{
+			UserID:    reused.UserID,
+			RevokedAt: sql.NullTime{Time: now, Valid: true},
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		return createNotification(r.Context(), q, reused.UserID, notificationRefreshTokenReused, refreshTokenReusedData{
+			FamilyID:   reused.FamilyID,
+			DetectedAt: now,
+		})
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not revoke tokens")
+		log.Printf("error revoking refresh tokens of user %s after reuse in family %s: %v", reused.UserID, reused.FamilyID, err)
+		return
+	}
+
+	log.Printf("revoked every refresh token of user %s after reuse in family %s", reused.UserID, reused.FamilyID)
+	respondWithError(w, http.StatusUnauthorized, "refresh token has been revoked")
 }
 
 func (cfg *apiConfig) handlerRevokeRefreshToken(w http.ResponseWriter, r *http.Request) {
@@ -245,17 +687,36 @@ func (cfg *apiConfig) handlerRevokeRefreshToken(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	_, err = cfg.db.RevokeRefreshToken(r.Context(), database.RevokeRefreshTokenParams{
-		Token:     refreshTokenID,
-		RevokedAt: sql.NullTime{
-			Time:  time.Now().UTC(),
-			Valid: true,
-		},
-		UpdatedAt: time.Now().UTC(),
+	err = cfg.db.ExecTx(r.Context(), func(q *database.Queries) error {
+		revokedToken, err := q.RevokeRefreshToken(r.Context(), database.RevokeRefreshTokenParams{
+			Token:     refreshTokenID,
+			RevokedAt: sql.NullTime{
+				Time:  time.Now().UTC(),
+				Valid: true,
+			},
+			UpdatedAt: time.Now().UTC(),
+		})
+		if err == sql.ErrNoRows {
+			// Revoking a token twice is not an error, but it is left as it
+			// was and notified only once. Unknown tokens still are errors.
+			_, err = q.GetRefreshTokenByToken(r.Context(), refreshTokenID)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		return createNotification(r.Context(), q, revokedToken.UserID, notificationRefreshTokenRevoked, refreshTokenRevokedData{
+			RevokedAt: revokedToken.RevokedAt.Time,
+		})
 	})
 	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "could not revoke token")
 		log.Printf("error revoking refresh token: %v", err)
+		return
 	}
 	
 	w.WriteHeader(http.StatusNoContent)
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/auth"
+	"github.com/leonardomlouzas/GOose/internal/database"
+)
+
+func TestRefreshTokenReused(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(d time.Duration) sql.NullTime {
+		return sql.NullTime{Time: now.Add(d), Valid: true}
+	}
+
+	tests := []struct {
+		name  string
+		token database.RefreshToken
+		want  bool
+	}{
+		{"active", database.RefreshToken{}, false},
+		{"revoked", database.RefreshToken{RevokedAt: at(-time.Hour)}, true},
+		{"rotated within the grace period", database.RefreshToken{RevokedAt: at(-time.Second), RotatedAt: at(-time.Second)}, false},
+		{"rotated at the end of the grace period", database.RefreshToken{RevokedAt: at(-refreshTokenReuseGrace), RotatedAt: at(-refreshTokenReuseGrace)}, false},
+		{"rotated before the grace period", database.RefreshToken{RevokedAt: at(-time.Minute), RotatedAt: at(-time.Minute)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := refreshTokenReused(tt.token, now); got != tt.want {
+				t.Errorf("refreshTokenReused = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// createTestRefreshToken stores a refresh token of userID starting a new
+// family.
+func createTestRefreshToken(t *testing.T, cfg *apiConfig, userID uuid.UUID) string {
+	t.Helper()
+
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("making refresh token: %v", err)
+	}
+	_, err = cfg.db.InsertRefreshTokenIntoDB(context.Background(), database.InsertRefreshTokenIntoDBParams{
+		Token:     token,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    userID,
+		ExpiresAt: time.Now().UTC().Add(refreshTokenDuration),
+		FamilyID:  uuid.New(),
+	})
+	if err != nil {
+		t.Fatalf("inserting refresh token: %v", err)
+	}
+	return token
+}
+
+func refreshWith(t *testing.T, cfg *apiConfig, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/refresh", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	cfg.handlerRefreshToken(w, r)
+	return w
+}
+
+// rotateWith refreshes token and returns the one replacing it.
+func rotateWith(t *testing.T, cfg *apiConfig, token string) string {
+	t.Helper()
+
+	w := refreshWith(t, cfg, token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	response := struct {
+		RefreshToken string `json:"refresh_token"`
+	}{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decoding refresh response: %v", err)
+	}
+	return response.RefreshToken
+}
+
+func assertRefreshTokenRevoked(t *testing.T, cfg *apiConfig, token string, want bool) {
+	t.Helper()
+
+	refreshToken, err := cfg.db.GetRefreshTokenByToken(context.Background(), token)
+	if err != nil {
+		t.Fatalf("GetRefreshTokenByToken: %v", err)
+	}
+	if refreshToken.RevokedAt.Valid != want {
+		t.Errorf("token revoked = %v, want %v", refreshToken.RevokedAt.Valid, want)
+	}
+}
+
+func TestRefreshTokenReuseWithinGracePeriod(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com", "password")
+	first := createTestRefreshToken(t, cfg, user.ID)
+	otherSession := createTestRefreshToken(t, cfg, user.ID)
+
+	second := rotateWith(t, cfg, first)
+
+	// A second client sharing the token refreshes right after the first.
+	if w := refreshWith(t, cfg, first); w.Code != http.StatusUnauthorized {
+		t.Fatalf("reuse status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	assertRefreshTokenRevoked(t, cfg, second, false)
+	assertRefreshTokenRevoked(t, cfg, otherSession, false)
+	rotateWith(t, cfg, second)
+}
+
+func TestRefreshTokenReuseRevokesEveryToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		revoke func(t *testing.T, cfg *apiConfig, token string)
+	}{
+		{
+			name: "rotated",
+			revoke: func(t *testing.T, cfg *apiConfig, token string) {
+				_, err := cfg.db.RotateRefreshToken(context.Background(), database.RotateRefreshTokenParams{
+					Token:     token,
+					RotatedAt: sql.NullTime{Time: time.Now().UTC().Add(-time.Minute), Valid: true},
+				})
+				if err != nil {
+					t.Fatalf("RotateRefreshToken: %v", err)
+				}
+			},
+		},
+		{
+			name: "revoked",
+			revoke: func(t *testing.T, cfg *apiConfig, token string) {
+				r := httptest.NewRequest(http.MethodPost, "/api/revoke", nil)
+				r.Header.Set("Authorization", "Bearer "+token)
+				w := httptest.NewRecorder()
+				cfg.handlerRevokeRefreshToken(w, r)
+				if w.Code != http.StatusNoContent {
+					t.Fatalf("revoke status = %d, want %d: %s", w.Code, http.StatusNoContent, w.Body)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _ := newTestConfig(t)
+			user := createTestUser(t, cfg, "walt@example.com", "password")
+			other := createTestUser(t, cfg, "jesse@example.com", "password")
+			stolen := createTestRefreshToken(t, cfg, user.ID)
+			otherSession := createTestRefreshToken(t, cfg, user.ID)
+			otherUser := createTestRefreshToken(t, cfg, other.ID)
+
+			tt.revoke(t, cfg, stolen)
+			if w := refreshWith(t, cfg, stolen); w.Code != http.StatusUnauthorized {
+				t.Fatalf("reuse status = %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+
+			assertRefreshTokenRevoked(t, cfg, otherSession, true)
+			assertRefreshTokenRevoked(t, cfg, otherUser, false)
+			if w := refreshWith(t, cfg, otherSession); w.Code != http.StatusUnauthorized {
+				t.Errorf("refresh with another session status = %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+	"github.com/leonardomlouzas/GOose/internal/auth"
+	"github.com/leonardomlouzas/GOose/internal/broker"
+)
+
+const wsWriteWait = 10 * time.Second
+const wsPongWait = 60 * time.Second
+const wsPingInterval = 30 * time.Second
+const wsMaxMessageSize = 4096
+
+// wsProtocol is the subprotocol the server selects. Browsers fail the
+// connection when they offer subprotocols and none is selected, so clients
+// passing their token as a subprotocol have to offer this one too.
+const wsProtocol = "goose.v1"
+const wsTokenProtocolPrefix = "bearer."
+
+// wsRequest is a message sent by a websocket client. Type is "subscribe" or
+// "unsubscribe"; the other fields say what to (un)subscribe from.
+type wsRequest struct {
+	Type		string		`json:"type"`
+	All			bool		`json:"all"`
+	AuthorIDs	[]uuid.UUID	`json:"author_ids"`
+	ChirpIDs	[]uuid.UUID	`json:"chirp_ids"`
+}
+
+// wsMessage is a message sent to a websocket client.
+type wsMessage struct {
+	Type		string			`json:"type"`
+	EventID		uint64			`json:"event_id,omitempty"`
+	Data		json.RawMessage	`json:"data,omitempty"`
+	Message		string			`json:"message,omitempty"`
+	All			*bool			`json:"all,omitempty"`
+	AuthorIDs	[]uuid.UUID		`json:"author_ids,omitempty"`
+	ChirpIDs	[]uuid.UUID		`json:"chirp_ids,omitempty"`
+}
+
+// wsSubscriptions is what a single connection asked to receive. It is read by
+// the broker while publishing, so every access goes through mu.
+type wsSubscriptions struct {
+	mu		sync.Mutex
+	all		bool
+	authors	map[uuid.UUID]struct{}
+	chirps	map[uuid.UUID]struct{}
+}
+
+// handlerWebSocket serves /api/ws. The access token is read from the
+// Authorization header or, for browsers that cannot set it, from a
+// "bearer.<token>" subprotocol offered next to wsProtocol. Unlike a query
+// parameter, it stays out of access logs. Events the user's blocks and mutes
+// hide are left out, and the connection is closed when the token expires.
+func (cfg *apiConfig) handlerWebSocket(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		token = wsProtocolToken(r)
+	}
+	userID, expiresAt, err := auth.ValidateJWTWithExpiry(token, cfg.keyring)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols: []string{wsProtocol},
+	})
+	if err != nil {
+		log.Printf("error upgrading websocket connection for user %s: %v", userID, err)
+		return
+	}
+	defer conn.CloseNow()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	viewer := &streamViewer{ID: uuid.NullUUID{UUID: userID, Valid: true}}
+	subscriptions := &wsSubscriptions{
+		authors: make(map[uuid.UUID]struct{}),
+		chirps:  make(map[uuid.UUID]struct{}),
+	}
+	sub, _ := cfg.events.Subscribe(0, subscriptions.accepts)
+	defer sub.Unsubscribe()
+
+	conn.SetReadLimit(wsMaxMessageSize)
+
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		readWebSocketRequests(ctx, conn, subscriptions)
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	expiry := time.NewTimer(time.Until(expiresAt))
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-readDone:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// The client is reading slower than chirps arrive.
+				conn.Close(websocket.StatusTryAgainLater, "too many pending events")
+				return
+			}
+			if cfg.hidesEvent(ctx, viewer, event) {
+				continue
+			}
+			err := writeWebSocketMessage(ctx, conn, wsMessage{
+				Type:    event.Type,
+				EventID: event.ID,
+				Data:    event.Data,
+			})
+			if err != nil {
+				return
+			}
+		case <-ping.C:
+			// Ping waits for the pong, which the reading goroutine receives.
+			pingCtx, cancelPing := context.WithTimeout(ctx, wsPongWait)
+			err := conn.Ping(pingCtx)
+			cancelPing()
+			if err != nil {
+				return
+			}
+		case <-expiry.C:
+			conn.Close(websocket.StatusPolicyViolation, "token expired")
+			return
+		}
+	}
+}
+
+// readWebSocketRequests applies subscription requests until the client goes
+// away or breaks the protocol.
+func readWebSocketRequests(ctx context.Context, conn *websocket.Conn, subscriptions *wsSubscriptions) {
+	for {
+		messageType, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		if messageType != websocket.MessageText {
+			writeWebSocketMessage(ctx, conn, wsMessage{Type: "error", Message: "messages must be JSON text"})
+			continue
+		}
+
+		request := wsRequest{}
+		if err := json.Unmarshal(data, &request); err != nil {
+			writeWebSocketMessage(ctx, conn, wsMessage{Type: "error", Message: "invalid message"})
+			continue
+		}
+
+		switch request.Type {
+		case "subscribe":
+			subscriptions.update(request, true)
+		case "unsubscribe":
+			subscriptions.update(request, false)
+		default:
+			writeWebSocketMessage(ctx, conn, wsMessage{Type: "error", Message: "unknown message type"})
+			continue
+		}
+		writeWebSocketMessage(ctx, conn, subscriptions.snapshot())
+	}
+}
+
+func writeWebSocketMessage(ctx context.Context, conn *websocket.Conn, message wsMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, wsWriteWait)
+	defer cancel()
+	return conn.Write(ctx, websocket.MessageText, data)
+}
+
+func (s *wsSubscriptions) update(request wsRequest, subscribe bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if request.All {
+		s.all = subscribe
+	}
+	for _, authorID := range request.AuthorIDs {
+		if subscribe {
+			s.authors[authorID] = struct{}{}
+		} else {
+			delete(s.authors, authorID)
+		}
+	}
+	for _, chirpID := range request.ChirpIDs {
+		if subscribe {
+			s.chirps[chirpID] = struct{}{}
+		} else {
+			delete(s.chirps, chirpID)
+		}
+	}
+}
+
+// snapshot describes the current subscriptions, sent back after every change.
+func (s *wsSubscriptions) snapshot() wsMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := s.all
+	message := wsMessage{
+		Type:      "subscriptions",
+		All:       &all,
+		AuthorIDs: []uuid.UUID{},
+		ChirpIDs:  []uuid.UUID{},
+	}
+	for authorID := range s.authors {
+		message.AuthorIDs = append(message.AuthorIDs, authorID)
+	}
+	for chirpID := range s.chirps {
+		message.ChirpIDs = append(message.ChirpIDs, chirpID)
+	}
+	return message
+}
+
+func (s *wsSubscriptions) accepts(event broker.Event) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.all {
+		return true
+	}
+	if _, ok := s.authors[event.AuthorID]; ok {
+		return true
+	}
+	for _, chirpID := range event.ChirpIDs {
+		if _, ok := s.chirps[chirpID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// wsProtocolToken returns the access token offered as a subprotocol, if any.
+func wsProtocolToken(r *http.Request) string {
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(header, ",") {
+			if token, ok := strings.CutPrefix(strings.TrimSpace(protocol), wsTokenProtocolPrefix); ok {
+				return token
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWSProtocolToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		want    string
+	}{
+		{"no protocols", nil, ""},
+		{"no token", []string{"goose.v1"}, ""},
+		{"listed in one header", []string{"goose.v1, bearer.the-token"}, "the-token"},
+		{"separate headers", []string{"goose.v1", "bearer.the-token"}, "the-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/ws", nil)
+			for _, header := range tt.headers {
+				r.Header.Add("Sec-WebSocket-Protocol", header)
+			}
+			if got := wsProtocolToken(r); got != tt.want {
+				t.Errorf("wsProtocolToken = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}